@@ -2,8 +2,7 @@ package internal
 
 import (
 	"context"
-	"encoding/base64"
-	"encoding/json"
+	"fmt"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
@@ -30,15 +29,15 @@ func (usr User) Equals(other User) bool {
 	return usr.ID == other.ID
 }
 
-func SetCookie(c echo.Context, usr User) error {
-	data, err := json.Marshal(usr)
+func SetCookie(c echo.Context, codec *CookieCodec, usr User) error {
+	value, err := codec.Seal(usr)
 	if err != nil {
 		return err
 	}
 
 	c.SetCookie(&http.Cookie{
 		Name:     CookieName,
-		Value:    base64.RawURLEncoding.EncodeToString(data),
+		Value:    value,
 		Path:     "/",
 		MaxAge:   CookieMaxAgeInSeconds,
 		HttpOnly: true,
@@ -48,6 +47,17 @@ func SetCookie(c echo.Context, usr User) error {
 	return nil
 }
 
+// ClearCookie asks the browser to remove the session cookie.
+func ClearCookie(c echo.Context) {
+	c.SetCookie(&http.Cookie{
+		Name:     CookieName,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
 func GetUser(ctx context.Context) (User, error) {
 	usr, ok := ctx.Value(KeyUser).(User)
 	if !ok {
@@ -56,3 +66,17 @@ func GetUser(ctx context.Context) (User, error) {
 
 	return usr, nil
 }
+
+// GetTeamUser returns the current user, who must have joined a team's session before managing its settings.
+func GetTeamUser(ctx context.Context) (User, error) {
+	usr, err := GetUser(ctx)
+	if err != nil {
+		return usr, err
+	}
+
+	if usr.Team == "" {
+		return usr, fmt.Errorf("%w: %s is not member of any team", ErrUnauthorized, usr.Name)
+	}
+
+	return usr, nil
+}
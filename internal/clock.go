@@ -1,18 +1,41 @@
 package internal
 
 import (
+	"slices"
+	"sync"
 	"time"
 )
 
 type (
 	Clock interface {
 		Now() time.Time
+		// NewTicker returns a ticker sending the current time every d, dropping ticks for slow receivers.
+		NewTicker(d time.Duration) Ticker
+	}
+
+	Ticker interface {
+		C() <-chan time.Time
+		Stop()
 	}
 
 	UTCClock struct{}
 
+	utcTicker struct {
+		ticker *time.Ticker
+	}
+
+	// FixedClock only moves forward when advanced, firing its tickers, so that tests control time.
 	FixedClock struct {
-		now time.Time
+		mu      sync.Mutex
+		now     time.Time
+		tickers []*fixedTicker
+	}
+
+	fixedTicker struct {
+		clk  *FixedClock
+		c    chan time.Time
+		d    time.Duration
+		next time.Time
 	}
 )
 
@@ -20,10 +43,71 @@ func (clk *UTCClock) Now() time.Time {
 	return time.Now().UTC()
 }
 
-func NewFixedClock(now time.Time) FixedClock {
-	return FixedClock{now: now}
+func (clk *UTCClock) NewTicker(d time.Duration) Ticker { //nolint:ireturn
+	return utcTicker{ticker: time.NewTicker(d)}
+}
+
+func (tck utcTicker) C() <-chan time.Time {
+	return tck.ticker.C
 }
 
-func (clk FixedClock) Now() time.Time {
+func (tck utcTicker) Stop() {
+	tck.ticker.Stop()
+}
+
+func NewFixedClock(now time.Time) *FixedClock {
+	return &FixedClock{now: now}
+}
+
+func (clk *FixedClock) Now() time.Time {
+	clk.mu.Lock()
+	defer clk.mu.Unlock()
+
 	return clk.now
 }
+
+func (clk *FixedClock) NewTicker(d time.Duration) Ticker { //nolint:ireturn
+	clk.mu.Lock()
+	defer clk.mu.Unlock()
+
+	res := &fixedTicker{
+		clk:  clk,
+		c:    make(chan time.Time, 1),
+		d:    d,
+		next: clk.now.Add(d),
+	}
+
+	clk.tickers = append(clk.tickers, res)
+
+	return res
+}
+
+// Advance moves the clock forward, firing the tickers due in the meantime.
+func (clk *FixedClock) Advance(d time.Duration) {
+	clk.mu.Lock()
+	defer clk.mu.Unlock()
+
+	clk.now = clk.now.Add(d)
+
+	for _, tck := range clk.tickers {
+		for !tck.next.After(clk.now) {
+			select {
+			case tck.c <- tck.next:
+			default:
+			}
+
+			tck.next = tck.next.Add(tck.d)
+		}
+	}
+}
+
+func (tck *fixedTicker) C() <-chan time.Time {
+	return tck.c
+}
+
+func (tck *fixedTicker) Stop() {
+	tck.clk.mu.Lock()
+	defer tck.clk.mu.Unlock()
+
+	tck.clk.tickers = slices.DeleteFunc(tck.clk.tickers, func(other *fixedTicker) bool { return other == tck })
+}
@@ -9,13 +9,26 @@ import (
 )
 
 type Config struct {
+	CookieEncryption  bool
+	CookieKeys        []string
 	DatabaseURL       string
 	Dev               bool
 	EmptySessionsTick time.Duration `envDefault:"1h"`
+	Heartbeat         time.Duration `envDefault:"30s"`
 	Host              string
 	MaxInactiveTime   time.Duration `envDefault:"5s"`
+	OIDCClientID      string
+	OIDCClientSecret  string
+	OIDCIssuer        string
+	OIDCRedirectURL   string
 	Path              string
-	Port              int `envDefault:"8080"`
+	Port              int           `envDefault:"8080"`
+	RejoinDelay       time.Duration `envDefault:"5m"`
+	SSERetry          time.Duration `envDefault:"3s"`
+	SummaryHosts      []string
+	SummaryTimeout    time.Duration `envDefault:"3s"`
+	TrackerTimeout    time.Duration `envDefault:"10s"`
+	WebhookTimeout    time.Duration `envDefault:"10s"`
 }
 
 func ParseConfig() (Config, error) {
@@ -29,6 +42,11 @@ func ParseConfig() (Config, error) {
 	return cfg, err
 }
 
+// OIDCEnabled reports whether users sign in with OpenID Connect, instead of typing their username.
+func (cfg Config) OIDCEnabled() bool {
+	return cfg.OIDCIssuer != ""
+}
+
 func (cfg Config) Address() string {
 	return net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
 }
@@ -0,0 +1,228 @@
+package internal
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+const cookieKeySize = 32
+
+// secretPrefix marks secrets encrypted by SealSecret, from the ones saved in clear before.
+const secretPrefix = "enc:"
+
+// CookieCodec signs, and optionally encrypts, the values stored in cookies, like the user of the session cookie.
+// The first key signs new cookies, while all keys are accepted to verify existing ones, so that keys can be rotated.
+// It also encrypts secrets stored in the database, with keys of their own derived from the same secrets.
+type CookieCodec struct {
+	encrypt bool
+	keys    []cookieKey
+}
+
+type cookieKey struct {
+	sign    []byte
+	encrypt cipher.AEAD
+	secret  cipher.AEAD
+}
+
+func NewCookieCodec(cfg Config) (*CookieCodec, error) {
+	secrets := cfg.CookieKeys
+
+	if len(secrets) == 0 {
+		if !cfg.Dev {
+			return nil, fmt.Errorf("%w: at least one cookie key is required", ErrInvalidInput)
+		}
+
+		slog.Warn("No cookie key, using a random one: cookies will not survive restarts")
+
+		secret := make([]byte, cookieKeySize)
+
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+
+		secrets = []string{string(secret)}
+	}
+
+	res := &CookieCodec{
+		encrypt: cfg.CookieEncryption,
+		keys:    make([]cookieKey, len(secrets)),
+	}
+
+	for i, secret := range secrets {
+		if len(secret) < cookieKeySize {
+			return nil, fmt.Errorf("%w: cookie key #%d must be at least %d characters", ErrInvalidInput, i+1, cookieKeySize)
+		}
+
+		key, err := newCookieKey(secret)
+		if err != nil {
+			return nil, err
+		}
+
+		res.keys[i] = key
+	}
+
+	return res, nil
+}
+
+// newCookieKey derives distinct signing and encryption keys from the secret.
+func newCookieKey(secret string) (cookieKey, error) {
+	encrypt, err := newAEAD(deriveKey(secret, "encrypt"))
+	if err != nil {
+		return cookieKey{}, err
+	}
+
+	secretKey, err := newAEAD(deriveKey(secret, "secret"))
+	if err != nil {
+		return cookieKey{}, err
+	}
+
+	return cookieKey{
+		sign:    deriveKey(secret, "sign"),
+		encrypt: encrypt,
+		secret:  secretKey,
+	}, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) { //nolint:ireturn
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func deriveKey(secret, purpose string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(purpose))
+
+	return mac.Sum(nil)
+}
+
+// Seal returns the JSON payload of v and its signature, both base64 encoded and separated by a dot.
+func (codec *CookieCodec) Seal(v any) (string, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	key := codec.keys[0]
+
+	if codec.encrypt {
+		nonce := make([]byte, key.encrypt.NonceSize())
+
+		if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+			return "", err
+		}
+
+		payload = key.encrypt.Seal(nonce, nonce, payload, nil)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(sign(key, payload)), nil
+}
+
+// Open decodes into v a value sealed by any of the keys, otherwise it returns ErrUnauthorized.
+func (codec *CookieCodec) Open(value string, v any) error {
+	encodedPayload, encodedSignature, found := strings.Cut(value, ".")
+	if !found {
+		return fmt.Errorf("%w: unsigned cookie", ErrUnauthorized)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUnauthorized, err)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUnauthorized, err)
+	}
+
+	for _, key := range codec.keys {
+		if !hmac.Equal(signature, sign(key, payload)) {
+			continue
+		}
+
+		if codec.encrypt {
+			if payload, err = decrypt(key, payload); err != nil {
+				return fmt.Errorf("%w: %w", ErrUnauthorized, err)
+			}
+		}
+
+		if err = json.Unmarshal(payload, v); err != nil {
+			return fmt.Errorf("%w: %w", ErrUnauthorized, err)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("%w: invalid cookie signature", ErrUnauthorized)
+}
+
+// SealSecret encrypts the secret with the first key, like an access token to be stored in the database.
+func (codec *CookieCodec) SealSecret(secret string) (string, error) {
+	key := codec.keys[0].secret
+	nonce := make([]byte, key.NonceSize())
+
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	return secretPrefix + base64.RawURLEncoding.EncodeToString(key.Seal(nonce, nonce, []byte(secret), nil)), nil
+}
+
+// OpenSecret decrypts a secret sealed by any of the keys, or returns as is a secret saved in clear.
+// Once the key that sealed a secret is removed, the secret must be saved again.
+func (codec *CookieCodec) OpenSecret(value string) (string, error) {
+	encoded, found := strings.CutPrefix(value, secretPrefix)
+	if !found {
+		return value, nil
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+
+	for _, key := range codec.keys {
+		nonceSize := key.secret.NonceSize()
+
+		if len(payload) < nonceSize {
+			return "", errors.New("encrypted secret too short")
+		}
+
+		if secret, err := key.secret.Open(nil, payload[:nonceSize], payload[nonceSize:], nil); err == nil {
+			return string(secret), nil
+		}
+	}
+
+	return "", errors.New("secret sealed by an unknown key")
+}
+
+func sign(key cookieKey, payload []byte) []byte {
+	mac := hmac.New(sha256.New, key.sign)
+	mac.Write(payload)
+
+	return mac.Sum(nil)
+}
+
+func decrypt(key cookieKey, payload []byte) ([]byte, error) {
+	nonceSize := key.encrypt.NonceSize()
+
+	if len(payload) < nonceSize {
+		return nil, errors.New("encrypted payload too short")
+	}
+
+	return key.encrypt.Open(nil, payload[:nonceSize], payload[nonceSize:], nil)
+}
@@ -0,0 +1,46 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func testCodec(t *testing.T, keys ...string) *CookieCodec {
+	t.Helper()
+
+	codec, err := NewCookieCodec(Config{CookieKeys: keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return codec
+}
+
+func TestCookieCodec_SealSecret(t *testing.T) {
+	oldKey, newKey := strings.Repeat("o", cookieKeySize), strings.Repeat("n", cookieKeySize)
+	old := testCodec(t, oldKey)
+	rotated := testCodec(t, newKey, oldKey)
+
+	sealed, err := old.SealSecret("token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(sealed, "token") {
+		t.Errorf("secret sealed in clear: %s", sealed)
+	}
+
+	for name, codec := range map[string]*CookieCodec{"same key": old, "rotated keys": rotated} {
+		if got, err := codec.OpenSecret(sealed); err != nil || got != "token" {
+			t.Errorf("%s: OpenSecret = %q, %v", name, got, err)
+		}
+	}
+
+	if _, err = testCodec(t, newKey).OpenSecret(sealed); err == nil {
+		t.Error("opened a secret sealed by a removed key")
+	}
+
+	if got, err := rotated.OpenSecret("saved-in-clear"); err != nil || got != "saved-in-clear" {
+		t.Errorf("OpenSecret = %q, %v", got, err)
+	}
+}
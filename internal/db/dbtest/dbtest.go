@@ -0,0 +1,45 @@
+// Package dbtest provides a migrated database to tests, the ones using it being skipped without one.
+package dbtest
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/MartyHub/size-it/internal/db"
+)
+
+// EnvDatabaseURL names the variable holding the connection string of the test database, see make test_db.
+const EnvDatabaseURL = "SIZE_IT_TEST_DATABASE_URL"
+
+// Repository connects to the test database, migrated to the latest version, skipping the test if not configured.
+func Repository(t *testing.T) *db.Repository {
+	t.Helper()
+
+	connString := os.Getenv(EnvDatabaseURL)
+	if connString == "" {
+		t.Skip(EnvDatabaseURL + " is not set")
+	}
+
+	ctx := context.Background()
+
+	repo, err := db.NewRepository(ctx, connString)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(repo.Close)
+
+	if err = repo.Migrate(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	return repo
+}
+
+// Team returns a team of its own to the test, so that tests don't see the data of each other.
+func Team() string {
+	return "test-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+}
@@ -0,0 +1,2 @@
+alter table session
+    add column facilitator_id varchar(26) not null default '';
@@ -0,0 +1,67 @@
+create table deck
+(
+    id         bigint      not null generated by default as identity,
+    team       varchar(32) null,
+    name       varchar(32) not null,
+    created_at timestamp   not null,
+    constraint deck_pk primary key (id)
+);
+
+create unique index deck_team_name_ux on deck (coalesce(team, ''), name);
+
+create table deck_card
+(
+    deck_id  bigint           not null,
+    position smallint         not null,
+    value    varchar(8)       not null,
+    weight   double precision null,
+    constraint deck_card_pk primary key (deck_id, position)
+);
+
+alter table deck_card
+    add constraint deck_card_deck_id foreign key (deck_id) references deck (id) on delete cascade;
+
+-- Shared decks replacing the former hard-coded story points and T-shirt sizings
+insert into deck
+    (id, team, name, created_at) values
+    (1, null, 'Story Points', now()::timestamp),
+    (2, null, 'T-Shirt', now()::timestamp);
+
+alter table deck
+    alter column id restart with 3;
+
+insert into deck_card
+    (deck_id, position, value, weight) values
+    (1, 0, '1', 1),
+    (1, 1, '2', 2),
+    (1, 2, '3', 3),
+    (1, 3, '5', 5),
+    (1, 4, '8', 8),
+    (1, 5, '13', 13),
+    (1, 6, '20', 20),
+    (1, 7, '40', 40),
+    (1, 8, '﹖', null),
+    (2, 0, 'XS', 1),
+    (2, 1, 'S', 2),
+    (2, 2, 'M', 3),
+    (2, 3, 'L', 5),
+    (2, 4, 'XL', 8),
+    (2, 5, 'XXL', 13),
+    (2, 6, '﹖', null);
+
+alter table ticket
+    add column deck_id bigint null;
+
+update ticket
+   set deck_id = case sizing_type when 'T_SHIRT' then 2 else 1 end;
+
+alter table ticket
+    alter column deck_id set not null;
+
+alter table ticket
+    drop column sizing_type;
+
+alter table ticket
+    add constraint ticket_deck_id foreign key (deck_id) references deck (id);
+
+create index ticket_deck_ix on ticket (deck_id);
@@ -0,0 +1,3 @@
+alter table session
+    add column auto_reveal       boolean not null default false,
+    add column auto_reveal_delay integer not null default 0;
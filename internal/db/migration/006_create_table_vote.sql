@@ -0,0 +1,13 @@
+create table vote
+(
+    ticket_id  bigint      not null,
+    round      integer     not null,
+    user_id    varchar(26) not null,
+    user_name  varchar(32) not null,
+    value      varchar(8)  not null,
+    created_at timestamp   not null,
+    constraint vote_pk primary key (ticket_id, round, user_id)
+);
+
+alter table vote
+    add constraint vote_ticket_id foreign key (ticket_id) references ticket (id);
@@ -0,0 +1,10 @@
+create table live_state
+(
+    session_id varchar(26) not null,
+    data       jsonb       not null,
+    updated_at timestamp   not null,
+    constraint live_state_pk primary key (session_id)
+);
+
+alter table live_state
+    add constraint live_state_session_id foreign key (session_id) references session (id);
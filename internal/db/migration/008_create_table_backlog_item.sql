@@ -0,0 +1,15 @@
+create table backlog_item
+(
+    id         bigint       not null generated always as identity,
+    session_id varchar(26)  not null,
+    position   integer      not null,
+    summary    varchar(512) not null,
+    url        varchar(512) not null,
+    created_at timestamp    not null,
+    constraint backlog_item_pk primary key (id)
+);
+
+alter table backlog_item
+    add constraint backlog_item_session_id foreign key (session_id) references session (id);
+
+create index backlog_item_session_ix on backlog_item (session_id, position);
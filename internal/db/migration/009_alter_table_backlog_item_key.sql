@@ -0,0 +1,2 @@
+alter table backlog_item
+    add column key varchar(64) not null default '';
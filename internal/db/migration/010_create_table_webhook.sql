@@ -0,0 +1,35 @@
+create table webhook
+(
+    id         bigint        not null generated always as identity,
+    team       varchar(32)   not null,
+    url        varchar(512)  not null,
+    secret     varchar(128)  not null,
+    events     varchar(32)[] not null,
+    created_at timestamp     not null,
+    constraint webhook_pk primary key (id)
+);
+
+create index webhook_team_ix on webhook (team);
+
+create table webhook_delivery
+(
+    id              bigint       not null generated always as identity,
+    webhook_id      bigint       not null,
+    event           varchar(32)  not null,
+    payload         jsonb        not null,
+    status          varchar(16)  not null,
+    attempts        integer      not null,
+    response_status integer      not null,
+    error           varchar(512) not null,
+    created_at      timestamp    not null,
+    next_attempt_at timestamp    not null,
+    delivered_at    timestamp,
+    constraint webhook_delivery_pk primary key (id)
+);
+
+alter table webhook_delivery
+    add constraint webhook_delivery_webhook_id foreign key (webhook_id) references webhook (id) on delete cascade;
+
+create index webhook_delivery_webhook_ix on webhook_delivery (webhook_id, id);
+
+create index webhook_delivery_pending_ix on webhook_delivery (next_attempt_at) where status = 'pending';
@@ -0,0 +1,16 @@
+create table tracker
+(
+    id          bigint       not null generated always as identity,
+    team        varchar(32)  not null,
+    kind        varchar(16)  not null,
+    base_url    varchar(512) not null,
+    username    varchar(128) not null,
+    token       varchar(512) not null,
+    field       varchar(128) not null,
+    dry_run     boolean      not null,
+    created_at  timestamp    not null,
+    sync_status varchar(512) not null,
+    synced_at   timestamp,
+    constraint tracker_pk primary key (id),
+    constraint tracker_uk unique (team, base_url)
+);
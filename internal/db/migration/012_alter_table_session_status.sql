@@ -0,0 +1,4 @@
+alter table session
+    add column status    varchar(16) not null default 'open',
+    add column closed_at timestamp,
+    add constraint session_status_ck check (status in ('open', 'closed', 'archived'));
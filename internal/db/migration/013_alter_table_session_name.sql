@@ -0,0 +1,7 @@
+alter table session
+    add column name         varchar(64)  not null default '',
+    add column description  varchar(512) not null default '',
+    add column sprint       varchar(32)  not null default '',
+    add column scheduled_at timestamp;
+
+create index session_team_ix on session (team, coalesce(scheduled_at, created_at));
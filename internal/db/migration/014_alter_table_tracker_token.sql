@@ -0,0 +1,2 @@
+alter table tracker
+    alter column token type varchar(1024);
@@ -0,0 +1,2 @@
+alter table webhook
+    alter column secret type varchar(1024);
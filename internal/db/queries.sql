@@ -6,11 +6,41 @@ select *
 
 -- name: CreateSession :one
 insert into session
-    (id, team, created_at) values
-    (@id, @team, @created_at)
+    (id, team, facilitator_id, auto_reveal, auto_reveal_delay, name, description, sprint, scheduled_at, created_at) values
+    (@id, @team, @facilitator_id, @auto_reveal, @auto_reveal_delay, @name, @description, @sprint, @scheduled_at, @created_at)
 returning *
 ;
 
+-- name: TeamSessions :many
+select *
+  from session
+ where team = @team
+   and status <> 'archived'
+   and coalesce(scheduled_at, created_at) >= @since
+ order by coalesce(scheduled_at, created_at) desc
+ limit @max_sessions
+;
+
+-- name: UpdateSessionAutoReveal :exec
+update session set
+    auto_reveal       = @auto_reveal,
+    auto_reveal_delay = @auto_reveal_delay
+where id = @id
+;
+
+-- name: UpdateSessionFacilitator :exec
+update session set
+    facilitator_id = @facilitator_id
+where id = @id
+;
+
+-- name: UpdateSessionStatus :exec
+update session set
+    status    = @status,
+    closed_at = @closed_at
+where id = @id
+;
+
 -- name: Teams :many
 select distinct team
   from session
@@ -20,8 +50,8 @@ select distinct team
 
 -- name: CreateTicket :one
 insert into ticket
-    (session_id, summary, url, sizing_type, sizing_value) values
-    (@session_id, @summary, @url, @sizing_type, @sizing_value)
+    (session_id, summary, url, deck_id, sizing_value) values
+    (@session_id, @summary, @url, @deck_id, @sizing_value)
 returning *
 ;
 
@@ -29,17 +59,265 @@ returning *
 update ticket set
     summary      = @summary,
     url          = @url,
-    sizing_type  = @sizing_type,
+    deck_id      = @deck_id,
     sizing_value = @sizing_value
 where id = @id
 ;
 
+-- name: SessionTickets :many
+select *
+  from ticket
+ where session_id = @session_id
+ order by id
+;
+
+-- name: SessionTicketCounts :many
+select session_id, count(*) as tickets
+  from ticket
+ where session_id = any(@session_ids::varchar[])
+ group by session_id
+;
+
+-- name: SessionVotes :many
+select v.*
+  from vote v
+ inner join ticket t on t.id = v.ticket_id
+ where t.session_id = @session_id
+ order by v.ticket_id, v.round, v.created_at
+;
+
 -- name: History :many
 select t.*
   from ticket t
  inner join session s on s.id = t.session_id
                      and s.team = @team
                      and s.created_at >= 'now'::timestamp - '3 month'::interval
- where t.sizing_type = @sizing_type
+ where t.deck_id = @deck_id
  order by t.id desc
 ;
+
+-- name: Decks :many
+select *
+  from deck
+ where team is null
+    or team = @team::varchar
+ order by team nulls first, name
+;
+
+-- name: Deck :one
+select *
+  from deck
+ where id = @id
+;
+
+-- name: DeckCards :many
+select *
+  from deck_card
+ where deck_id = any (@deck_ids::bigint[])
+ order by deck_id, position
+;
+
+-- name: CreateDeck :one
+insert into deck
+    (team, name, created_at) values
+    (@team, @name, @created_at)
+returning *
+;
+
+-- name: UpdateDeck :exec
+update deck set
+    name = @name
+where id = @id
+;
+
+-- name: DeleteDeckCards :exec
+delete
+  from deck_card
+ where deck_id = @deck_id
+;
+
+-- name: CreateDeckCard :exec
+insert into deck_card
+    (deck_id, position, value, weight) values
+    (@deck_id, @position, @value, @weight)
+;
+
+-- name: CreateVote :exec
+insert into vote
+    (ticket_id, round, user_id, user_name, value, created_at) values
+    (@ticket_id, @round, @user_id, @user_name, @value, @created_at)
+;
+
+-- name: DeleteVotes :exec
+delete
+  from vote
+ where ticket_id = @ticket_id
+   and round = @round
+;
+
+-- name: LiveState :one
+select data
+  from live_state
+ where session_id = @session_id
+;
+
+-- name: SaveLiveState :exec
+insert into live_state
+    (session_id, data, updated_at) values
+    (@session_id, @data, @updated_at)
+on conflict (session_id) do update set
+    data       = excluded.data,
+    updated_at = excluded.updated_at
+;
+
+-- name: LiveStateForUpdate :one
+select data
+  from live_state
+ where session_id = @session_id
+   for update
+;
+
+-- name: NotifyLiveState :exec
+select pg_notify(@channel::text, @payload::text)
+;
+
+-- name: BacklogItems :many
+select *
+  from backlog_item
+ where session_id = @session_id
+ order by position, id
+;
+
+-- name: CreateBacklogItem :exec
+insert into backlog_item
+    (session_id, position, key, summary, url, created_at)
+select @session_id, coalesce(max(position) + 1, 0), @key, @summary, @url, @created_at
+  from backlog_item
+ where session_id = @session_id
+;
+
+-- name: UpdateBacklogItemPosition :exec
+update backlog_item set
+    position = @position
+where id = @id
+  and session_id = @session_id
+;
+
+-- name: DeleteBacklogItem :exec
+delete
+  from backlog_item
+ where id = @id
+   and session_id = @session_id
+;
+
+-- name: Webhooks :many
+select *
+  from webhook
+ where team = @team
+ order by id
+;
+
+-- name: Webhook :one
+select *
+  from webhook
+ where id = @id
+;
+
+-- name: EventWebhooks :many
+select *
+  from webhook
+ where team = @team
+   and @event::varchar = any (events)
+ order by id
+;
+
+-- name: CreateWebhook :one
+insert into webhook
+    (team, url, secret, events, created_at) values
+    (@team, @url, @secret, @events, @created_at)
+returning *
+;
+
+-- name: DeleteWebhook :exec
+delete
+  from webhook
+ where id = @id
+;
+
+-- name: WebhookDeliveries :many
+select *
+  from webhook_delivery
+ where webhook_id = @webhook_id
+ order by id desc
+ limit 50
+;
+
+-- name: WebhookDelivery :one
+select *
+  from webhook_delivery
+ where id = @id
+;
+
+-- name: CreateWebhookDelivery :one
+insert into webhook_delivery
+    (webhook_id, event, payload, status, attempts, response_status, error, created_at, next_attempt_at) values
+    (@webhook_id, @event, @payload, 'pending', 0, 0, '', @created_at, @created_at)
+returning *
+;
+
+-- name: ClaimWebhookDeliveries :many
+update webhook_delivery set
+    next_attempt_at = @lease_until
+where id in (select id
+               from webhook_delivery
+              where status = 'pending'
+                and next_attempt_at <= @now
+              order by next_attempt_at
+              limit @max_count
+                for update skip locked)
+returning *
+;
+
+-- name: UpdateWebhookDelivery :exec
+update webhook_delivery set
+    status          = @status,
+    attempts        = @attempts,
+    response_status = @response_status,
+    error           = @error,
+    next_attempt_at = @next_attempt_at,
+    delivered_at    = @delivered_at
+where id = @id
+;
+
+-- name: Trackers :many
+select *
+  from tracker
+ where team = @team
+ order by id
+;
+
+-- name: Tracker :one
+select *
+  from tracker
+ where id = @id
+;
+
+-- name: CreateTracker :one
+insert into tracker
+    (team, kind, base_url, username, token, field, dry_run, created_at, sync_status) values
+    (@team, @kind, @base_url, @username, @token, @field, @dry_run, @created_at, '')
+returning *
+;
+
+-- name: DeleteTracker :exec
+delete
+  from tracker
+ where id = @id
+;
+
+-- name: UpdateTrackerSync :exec
+update tracker set
+    sync_status = @sync_status,
+    synced_at   = @synced_at
+where id = @id
+;
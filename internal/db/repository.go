@@ -11,18 +11,29 @@ import (
 
 	"github.com/MartyHub/size-it/internal/db/sqlc"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jackc/tern/v2/migrate"
 	"github.com/oklog/ulid/v2"
 )
 
+const codeUniqueViolation = "23505"
+
+// Statuses of sessions: closed sessions can't be changed anymore, archived ones are not listed anymore.
+const (
+	SessionArchived = "archived"
+	SessionClosed   = "closed"
+	SessionOpen     = "open"
+)
+
 //go:embed migration/*.sql
 var migrations embed.FS
 
 type Repository struct {
 	*sqlc.Queries
 
-	pool *pgxpool.Pool
+	pool             *pgxpool.Pool
+	migrationVersion int32
 }
 
 func NewRepository(ctx context.Context, connString string) (*Repository, error) {
@@ -53,6 +64,73 @@ func (repo *Repository) Migrate(ctx context.Context) error {
 	})
 }
 
+// InTx runs fn within a database transaction, committed only if fn succeeds.
+func (repo *Repository) InTx(ctx context.Context, fn func(queries *sqlc.Queries) error) error {
+	return pgx.BeginFunc(ctx, repo.pool, func(tx pgx.Tx) error {
+		return fn(repo.WithTx(tx))
+	})
+}
+
+// Listen runs fn with a connection dedicated to LISTEN/NOTIFY, identified by the given application name.
+// The connection is closed afterward, instead of being released to the pool.
+func (repo *Repository) Listen(ctx context.Context, applicationName string, fn func(conn *pgx.Conn) error) error {
+	pooled, err := repo.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err = conn.Exec(ctx, "select set_config('application_name', $1, false)", applicationName); err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+// ApplicationNames returns the application names, starting with the given prefix, of the connected clients.
+func (repo *Repository) ApplicationNames(ctx context.Context, prefix string) ([]string, error) {
+	rows, err := repo.pool.Query(ctx,
+		"select application_name from pg_stat_activity where starts_with(application_name, $1)",
+		prefix,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows[string](rows, pgx.RowTo[string])
+}
+
+// MigrationVersion returns the version of the database schema, once migrated.
+func (repo *Repository) MigrationVersion() int32 {
+	return repo.migrationVersion
+}
+
+// SchemaVersion reads the version of the database schema, which may have been migrated by another replica.
+func (repo *Repository) SchemaVersion(ctx context.Context) (int32, error) {
+	rows, err := repo.pool.Query(ctx, "select version from schema_version")
+	if err != nil {
+		return 0, err
+	}
+
+	return pgx.CollectOneRow[int32](rows, pgx.RowTo[int32])
+}
+
+// LatestMigrationVersion returns the version of the database schema expected by this build.
+func LatestMigrationVersion() (int32, error) {
+	files, err := fs.Glob(migrations, "migration/*.sql")
+	if err != nil {
+		return 0, err
+	}
+
+	return int32(len(files)), nil
+}
+
+func (repo *Repository) Stat() *pgxpool.Stat {
+	return repo.pool.Stat()
+}
+
 func (repo *Repository) Ping(ctx context.Context) error {
 	return repo.pool.Ping(ctx)
 }
@@ -104,13 +182,25 @@ func (repo *Repository) doMigrate(ctx context.Context, conn *pgx.Conn) error {
 		slog.Info(fmt.Sprintf("Starting SQL migration # %d: %s...", seq, name))
 	}
 
-	return m.Migrate(ctx)
+	if err = m.Migrate(ctx); err != nil {
+		return err
+	}
+
+	repo.migrationVersion, err = m.GetCurrentVersion(ctx)
+
+	return err
 }
 
 func IsErrNoRows(err error) bool {
 	return errors.Is(err, pgx.ErrNoRows)
 }
 
+func IsErrUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+
+	return errors.As(err, &pgErr) && pgErr.Code == codeUniqueViolation
+}
+
 func NewID() (string, error) {
 	id, err := ulid.New(ulid.Now(), rand.Reader)
 	if err != nil {
@@ -0,0 +1,46 @@
+package deck
+
+import (
+	"github.com/MartyHub/size-it/internal/db/sqlc"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func toDeck(entity sqlc.Deck, cards []sqlc.DeckCard) Deck {
+	res := Deck{
+		ID:    entity.ID,
+		Team:  entity.Team.String,
+		Name:  entity.Name,
+		Cards: make([]Card, 0, len(cards)),
+	}
+
+	for _, card := range cards {
+		res.Cards = append(res.Cards, toCard(card))
+	}
+
+	return res
+}
+
+func toCard(entity sqlc.DeckCard) Card {
+	res := Card{Value: entity.Value}
+
+	if entity.Weight.Valid {
+		weight := entity.Weight.Float64
+		res.Weight = &weight
+	}
+
+	return res
+}
+
+func fromCard(deckID int64, position int, card Card) sqlc.CreateDeckCardParams {
+	res := sqlc.CreateDeckCardParams{
+		DeckID:   deckID,
+		Position: int16(position), //nolint:gosec
+		Value:    card.Value,
+	}
+
+	if card.Weight != nil {
+		res.Weight = pgtype.Float8{Float64: *card.Weight, Valid: true}
+	}
+
+	return res
+}
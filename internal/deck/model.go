@@ -0,0 +1,153 @@
+package deck
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxCards     = 32
+	maxValueSize = 8
+	minCards     = 2
+
+	// forbiddenValueChars can't be used in card values as they are sent as URL path segments.
+	forbiddenValueChars = "/?#%"
+)
+
+type (
+	Deck struct {
+		ID    int64  `json:"id"`
+		Team  string `json:"team,omitempty"`
+		Name  string `json:"name"`
+		Cards []Card `json:"cards"`
+	}
+
+	Card struct {
+		Value  string   `json:"value"`
+		Weight *float64 `json:"weight,omitempty"`
+	}
+)
+
+// Shared reports whether the deck is available to every team, shared decks are read-only.
+func (dck Deck) Shared() bool {
+	return dck.Team == ""
+}
+
+func (dck Deck) Card(value string) (Card, bool) {
+	for _, card := range dck.Cards {
+		if card.Value == value {
+			return card, true
+		}
+	}
+
+	return Card{}, false
+}
+
+func (dck Deck) Contains(value string) bool {
+	_, found := dck.Card(value)
+
+	return found
+}
+
+func (dck Deck) Values() []string {
+	res := make([]string, len(dck.Cards))
+
+	for i, card := range dck.Cards {
+		res[i] = card.Value
+	}
+
+	return res
+}
+
+// CardsText formats cards the way ParseCards reads them.
+func (dck Deck) CardsText() string {
+	lines := make([]string, len(dck.Cards))
+
+	for i, card := range dck.Cards {
+		lines[i] = card.String()
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (card Card) String() string {
+	if card.Weight == nil {
+		return card.Value
+	}
+
+	return card.Value + " " + strconv.FormatFloat(*card.Weight, 'f', -1, 64)
+}
+
+// ParseCards reads one card per line: its value, optionally followed by its numeric weight.
+func ParseCards(text string) ([]Card, error) {
+	var (
+		errs []error
+		res  []Card
+	)
+
+	values := make(map[string]bool)
+
+	for i, line := range strings.Split(text, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		card, err := parseCard(fields)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", i+1, err))
+
+			continue
+		}
+
+		if values[card.Value] {
+			errs = append(errs, fmt.Errorf("line %d: duplicate card %s", i+1, card.Value))
+
+			continue
+		}
+
+		values[card.Value] = true
+
+		res = append(res, card)
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	if len(res) < minCards || len(res) > maxCards {
+		return nil, fmt.Errorf("a deck must have between %d and %d cards", minCards, maxCards)
+	}
+
+	return res, nil
+}
+
+func parseCard(fields []string) (Card, error) {
+	if len(fields) > 2 { //nolint:mnd
+		return Card{}, errors.New("expected a value and an optional weight")
+	}
+
+	res := Card{Value: fields[0]}
+
+	if utf8.RuneCountInString(res.Value) > maxValueSize {
+		return Card{}, fmt.Errorf("card %s is longer than %d characters", res.Value, maxValueSize)
+	}
+
+	if strings.ContainsAny(res.Value, forbiddenValueChars) {
+		return Card{}, fmt.Errorf("card %s must not contain any of %s", res.Value, forbiddenValueChars)
+	}
+
+	if len(fields) == 2 { //nolint:mnd
+		weight, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return Card{}, fmt.Errorf("invalid weight %s for card %s", fields[1], res.Value)
+		}
+
+		res.Weight = &weight
+	}
+
+	return res, nil
+}
@@ -0,0 +1,155 @@
+package deck
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MartyHub/size-it/internal"
+	"github.com/MartyHub/size-it/internal/db"
+	"github.com/MartyHub/size-it/internal/db/sqlc"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type Service struct {
+	clk  internal.Clock
+	repo *db.Repository
+}
+
+func NewService(clk internal.Clock, repo *db.Repository) *Service {
+	return &Service{
+		clk:  clk,
+		repo: repo,
+	}
+}
+
+// List returns shared decks first, then the ones of the given team.
+func (svc *Service) List(ctx context.Context, team string) ([]Deck, error) {
+	entities, err := svc.repo.Decks(ctx, team)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, len(entities))
+
+	for i, entity := range entities {
+		ids[i] = entity.ID
+	}
+
+	cards, err := svc.repo.DeckCards(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	cardsByDeckID := make(map[int64][]sqlc.DeckCard, len(entities))
+
+	for _, card := range cards {
+		cardsByDeckID[card.DeckID] = append(cardsByDeckID[card.DeckID], card)
+	}
+
+	res := make([]Deck, len(entities))
+
+	for i, entity := range entities {
+		res[i] = toDeck(entity, cardsByDeckID[entity.ID])
+	}
+
+	return res, nil
+}
+
+func (svc *Service) Get(ctx context.Context, id int64) (Deck, error) {
+	entity, err := svc.repo.Deck(ctx, id)
+	if err != nil {
+		if db.IsErrNoRows(err) {
+			return Deck{}, fmt.Errorf("%w: deck %d", internal.ErrNotFound, id)
+		}
+
+		return Deck{}, err
+	}
+
+	cards, err := svc.repo.DeckCards(ctx, []int64{id})
+	if err != nil {
+		return Deck{}, err
+	}
+
+	return toDeck(entity, cards), nil
+}
+
+func (svc *Service) Create(ctx context.Context, team, name string, cards []Card) (Deck, error) {
+	var res Deck
+
+	err := svc.repo.InTx(ctx, func(queries *sqlc.Queries) error {
+		entity, err := queries.CreateDeck(ctx, sqlc.CreateDeckParams{
+			Team:      pgtype.Text{String: team, Valid: true},
+			Name:      name,
+			CreatedAt: pgtype.Timestamp{Time: svc.clk.Now(), Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		if err = createCards(ctx, queries, entity.ID, cards); err != nil {
+			return err
+		}
+
+		res = toDeck(entity, nil)
+		res.Cards = cards
+
+		return nil
+	})
+	if err != nil {
+		return Deck{}, toError(err, name)
+	}
+
+	return res, nil
+}
+
+func (svc *Service) Update(ctx context.Context, id int64, team, name string, cards []Card) (Deck, error) {
+	res, err := svc.Get(ctx, id)
+	if err != nil {
+		return Deck{}, err
+	}
+
+	if res.Shared() || res.Team != team {
+		return Deck{}, fmt.Errorf("%w: deck %s can't be edited by team %s", internal.ErrUnauthorized, res.Name, team)
+	}
+
+	err = svc.repo.InTx(ctx, func(queries *sqlc.Queries) error {
+		if err := queries.UpdateDeck(ctx, sqlc.UpdateDeckParams{
+			Name: name,
+			ID:   id,
+		}); err != nil {
+			return err
+		}
+
+		if err := queries.DeleteDeckCards(ctx, id); err != nil {
+			return err
+		}
+
+		return createCards(ctx, queries, id, cards)
+	})
+	if err != nil {
+		return Deck{}, toError(err, name)
+	}
+
+	res.Name = name
+	res.Cards = cards
+
+	return res, nil
+}
+
+func createCards(ctx context.Context, queries *sqlc.Queries, deckID int64, cards []Card) error {
+	for i, card := range cards {
+		if err := queries.CreateDeckCard(ctx, fromCard(deckID, i, card)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func toError(err error, name string) error {
+	if db.IsErrUniqueViolation(err) {
+		return fmt.Errorf("%w: deck %s already exists", internal.ErrInvalidInput, name)
+	}
+
+	return err
+}
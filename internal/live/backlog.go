@@ -0,0 +1,253 @@
+package live
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/MartyHub/size-it/internal"
+	"github.com/MartyHub/size-it/internal/db/sqlc"
+	"github.com/MartyHub/size-it/internal/pubsub"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// maxSummarySize is the size of the summary column of tickets.
+const maxSummarySize = 512
+
+// BacklogItem is a ticket waiting to be sized in a session.
+type BacklogItem struct {
+	ID       int64  `json:"id"`
+	Position int    `json:"position"`
+	Key      string `json:"key,omitempty"`
+	Summary  string `json:"summary"`
+	URL      string `json:"url"`
+}
+
+// AddToBacklog appends items to the backlog of the session.
+func (svc *Service) AddToBacklog(ctx context.Context, sessionID string, items []BacklogItem, usr internal.User) error {
+	return svc.updateBacklog(ctx, sessionID, usr, func(_ *state, queries *sqlc.Queries) error {
+		slog.Info("Adding backlog items...",
+			slog.String(internal.LogKeySession, sessionID),
+			slog.Int("count", len(items)),
+		)
+
+		for _, item := range items {
+			if err := queries.CreateBacklogItem(ctx, sqlc.CreateBacklogItemParams{
+				SessionID: sessionID,
+				Key:       item.Key,
+				Summary:   item.Summary,
+				Url:       item.URL,
+				CreatedAt: pgtype.Timestamp{Time: svc.clk.Now(), Valid: true},
+			}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// MoveBacklogItem moves an item of the backlog to the given position, starting at 0.
+func (svc *Service) MoveBacklogItem(
+	ctx context.Context,
+	sessionID string,
+	itemID int64,
+	position int,
+	usr internal.User,
+) error {
+	return svc.updateBacklog(ctx, sessionID, usr, func(s *state, queries *sqlc.Queries) error {
+		i, err := s.backlogItem(sessionID, itemID)
+		if err != nil {
+			return err
+		}
+
+		item := s.Backlog[i]
+		backlog := slices.Delete(slices.Clone(s.Backlog), i, i+1)
+		backlog = slices.Insert(backlog, min(max(position, 0), len(backlog)), item)
+
+		for j, item := range backlog {
+			if err = queries.UpdateBacklogItemPosition(ctx, sqlc.UpdateBacklogItemPositionParams{
+				Position:  int32(j), //nolint:gosec
+				ID:        item.ID,
+				SessionID: sessionID,
+			}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (svc *Service) RemoveBacklogItem(ctx context.Context, sessionID string, itemID int64, usr internal.User) error {
+	return svc.updateBacklog(ctx, sessionID, usr, func(s *state, queries *sqlc.Queries) error {
+		if _, err := s.backlogItem(sessionID, itemID); err != nil {
+			return err
+		}
+
+		return queries.DeleteBacklogItem(ctx, sqlc.DeleteBacklogItemParams{
+			ID:        itemID,
+			SessionID: sessionID,
+		})
+	})
+}
+
+// NextTicket saves the current ticket in history if it has been sized,
+// then starts sizing the first item of the backlog, removed from it.
+func (svc *Service) NextTicket(ctx context.Context, sessionID string, usr internal.User) error {
+	return svc.update(ctx, sessionID, func(s *state, queries *sqlc.Queries) error {
+		if err := s.checkFacilitator(sessionID, usr); err != nil {
+			return err
+		}
+
+		if err := svc.loadBacklog(ctx, queries, sessionID, s); err != nil {
+			return err
+		}
+
+		if len(s.Backlog) == 0 {
+			return fmt.Errorf("%w: backlog of session %s is empty", internal.ErrInvalidInput, sessionID)
+		}
+
+		s.Ticket.SizingValue = s.chosenValue(usr)
+
+		if s.Ticket.valid() {
+			if err := svc.saveTicket(ctx, queries, sessionID, s); err != nil {
+				return err
+			}
+
+			svc.publish(sessionID, s, pubsub.TicketSized)
+		}
+
+		if err := svc.stopCountdown(sessionID, s); err != nil {
+			return err
+		}
+
+		if err := svc.clearTimer(sessionID, s); err != nil {
+			return err
+		}
+
+		item := s.Backlog[0]
+
+		if err := queries.DeleteBacklogItem(ctx, sqlc.DeleteBacklogItemParams{
+			ID:        item.ID,
+			SessionID: sessionID,
+		}); err != nil {
+			return err
+		}
+
+		s.Backlog = s.Backlog[1:]
+
+		s.reset()
+
+		s.Ticket.Summary = item.title()
+		s.Ticket.URL = item.URL
+
+		if err := svc.ntf.notifyTicket(sessionID, s, allActiveUsers); err != nil {
+			return err
+		}
+
+		if err := svc.ntf.notifyTabs(sessionID, s, allActiveUsers); err != nil {
+			return err
+		}
+
+		if err := svc.ntf.notifyResults(sessionID, s); err != nil {
+			return err
+		}
+
+		if err := svc.ntf.notifyBacklog(sessionID, s, allActiveUsers); err != nil {
+			return err
+		}
+
+		return svc.refreshHistory(ctx, queries, sessionID, s)
+	})
+}
+
+// updateBacklog runs fn with the backlog freshly loaded, as it may have been changed by another replica,
+// then reloads and broadcasts it.
+func (svc *Service) updateBacklog(
+	ctx context.Context,
+	sessionID string,
+	usr internal.User,
+	fn func(s *state, queries *sqlc.Queries) error,
+) error {
+	return svc.update(ctx, sessionID, func(s *state, queries *sqlc.Queries) error {
+		if err := s.checkFacilitator(sessionID, usr); err != nil {
+			return err
+		}
+
+		if err := svc.loadBacklog(ctx, queries, sessionID, s); err != nil {
+			return err
+		}
+
+		if err := fn(s, queries); err != nil {
+			return err
+		}
+
+		if err := svc.loadBacklog(ctx, queries, sessionID, s); err != nil {
+			return err
+		}
+
+		return svc.ntf.notifyBacklog(sessionID, s, allActiveUsers)
+	})
+}
+
+// loadBacklog reads the backlog of the session from the database.
+// It must be called with the session state locked.
+func (svc *Service) loadBacklog(ctx context.Context, queries *sqlc.Queries, sessionID string, s *state) error {
+	items, err := queries.BacklogItems(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	s.Backlog = make([]BacklogItem, len(items))
+
+	for i, item := range items {
+		s.Backlog[i] = BacklogItem{
+			ID:       item.ID,
+			Position: i,
+			Key:      item.Key,
+			Summary:  item.Summary,
+			URL:      item.Url,
+		}
+	}
+
+	return nil
+}
+
+// title prefixes the summary by the key of the issue, unless it already contains it.
+func (item BacklogItem) title() string {
+	if item.Key == "" || strings.Contains(item.Summary, item.Key) {
+		return item.Summary
+	}
+
+	return truncate(item.Key+" "+item.Summary, maxSummarySize)
+}
+
+// Up returns the position to move the item up to.
+func (item BacklogItem) Up() int {
+	return item.Position - 1
+}
+
+// Down returns the position to move the item down to.
+func (item BacklogItem) Down() int {
+	return item.Position + 1
+}
+
+func (s *state) backlogItem(sessionID string, itemID int64) (int, error) {
+	i := slices.IndexFunc(s.Backlog, func(item BacklogItem) bool { return item.ID == itemID })
+	if i < 0 {
+		return i, fmt.Errorf("%w: backlog item %d in session %s", internal.ErrNotFound, itemID, sessionID)
+	}
+
+	return i, nil
+}
+
+func truncate(s string, size int) string {
+	if runes := []rune(s); len(runes) > size {
+		return string(runes[:size])
+	}
+
+	return s
+}
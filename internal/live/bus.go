@@ -0,0 +1,187 @@
+package live
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MartyHub/size-it/internal"
+	"github.com/MartyHub/size-it/internal/db"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	applicationPrefix = "size-it/"
+	channelPrefix     = "live_"
+	listenRetryDelay  = 5 * time.Second
+)
+
+// Bus receives the notifications sent by Store.Update from any replica.
+type Bus interface {
+	// Run calls handle for each notification of a subscribed session, until ctx is done.
+	Run(ctx context.Context, replicaID string, handle func(sessionID, replicaID string))
+	Subscribe(sessionID string)
+	Unsubscribe(sessionID string)
+	// Replicas returns the IDs of the running replicas.
+	Replicas(ctx context.Context) ([]string, error)
+}
+
+// RepositoryBus relies on PostgreSQL LISTEN/NOTIFY, with a channel per session.
+type RepositoryBus struct {
+	mu         sync.Mutex
+	sessionIDs map[string]bool
+	wake       chan struct{}
+
+	repo *db.Repository
+}
+
+func NewRepositoryBus(repo *db.Repository) *RepositoryBus {
+	return &RepositoryBus{
+		repo:       repo,
+		sessionIDs: make(map[string]bool),
+		wake:       make(chan struct{}, 1),
+	}
+}
+
+func (bus *RepositoryBus) Run(ctx context.Context, replicaID string, handle func(sessionID, replicaID string)) {
+	slog.Info("Starting live state listener", slog.String("replica", replicaID))
+
+	for {
+		err := bus.repo.Listen(ctx, applicationPrefix+replicaID, func(conn *pgx.Conn) error {
+			return bus.listen(ctx, conn, handle)
+		})
+
+		if ctx.Err() != nil {
+			slog.Info("Server is shutting down, stopping live state listener...")
+
+			return
+		}
+
+		internal.LogError("Failed to listen to live states", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(listenRetryDelay):
+		}
+	}
+}
+
+func (bus *RepositoryBus) Subscribe(sessionID string) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	bus.sessionIDs[sessionID] = true
+
+	bus.wakeUp()
+}
+
+func (bus *RepositoryBus) Unsubscribe(sessionID string) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	delete(bus.sessionIDs, sessionID)
+
+	bus.wakeUp()
+}
+
+func (bus *RepositoryBus) Replicas(ctx context.Context) ([]string, error) {
+	names, err := bus.repo.ApplicationNames(ctx, applicationPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]string, len(names))
+
+	for i, name := range names {
+		res[i] = strings.TrimPrefix(name, applicationPrefix)
+	}
+
+	return res, nil
+}
+
+func (bus *RepositoryBus) listen(ctx context.Context, conn *pgx.Conn, handle func(sessionID, replicaID string)) error {
+	listening := make(map[string]bool)
+
+	if err := bus.sync(ctx, conn, listening); err != nil {
+		return err
+	}
+
+	// notifications may have been missed while (re)connecting
+	for sessionID := range listening {
+		handle(sessionID, "")
+	}
+
+	for {
+		waitCtx, cancel := context.WithCancel(ctx)
+
+		go func() {
+			select {
+			case <-bus.wake:
+				cancel()
+			case <-waitCtx.Done():
+			}
+		}()
+
+		ntf, err := conn.WaitForNotification(waitCtx)
+		woken := waitCtx.Err() != nil
+
+		cancel()
+
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case err != nil && woken:
+			// sessions have been (un)subscribed
+		case err != nil:
+			return err
+		default:
+			handle(strings.TrimPrefix(ntf.Channel, channelPrefix), ntf.Payload)
+		}
+
+		if err = bus.sync(ctx, conn, listening); err != nil {
+			return err
+		}
+	}
+}
+
+// sync listens to the channels of subscribed sessions, and only those.
+func (bus *RepositoryBus) sync(ctx context.Context, conn *pgx.Conn, listening map[string]bool) error {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	for sessionID := range bus.sessionIDs {
+		if listening[sessionID] {
+			continue
+		}
+
+		if _, err := conn.Exec(ctx, "listen "+pgx.Identifier{channel(sessionID)}.Sanitize()); err != nil {
+			return err
+		}
+
+		listening[sessionID] = true
+	}
+
+	for sessionID := range listening {
+		if bus.sessionIDs[sessionID] {
+			continue
+		}
+
+		if _, err := conn.Exec(ctx, "unlisten "+pgx.Identifier{channel(sessionID)}.Sanitize()); err != nil {
+			return err
+		}
+
+		delete(listening, sessionID)
+	}
+
+	return nil
+}
+
+func (bus *RepositoryBus) wakeUp() {
+	select {
+	case bus.wake <- struct{}{}:
+	default:
+	}
+}
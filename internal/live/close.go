@@ -0,0 +1,69 @@
+package live
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/MartyHub/size-it/internal"
+	"github.com/MartyHub/size-it/internal/db"
+	"github.com/MartyHub/size-it/internal/db/sqlc"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Close ends the session, facilitator only: users are disconnected, and the session can't be changed anymore.
+func (svc *Service) Close(ctx context.Context, sessionID string, usr internal.User) error {
+	return svc.update(ctx, sessionID, func(s *state, queries *sqlc.Queries) error {
+		if err := s.checkFacilitator(sessionID, usr); err != nil {
+			return err
+		}
+
+		if err := queries.UpdateSessionStatus(ctx, sqlc.UpdateSessionStatusParams{
+			Status:   db.SessionClosed,
+			ClosedAt: pgtype.Timestamp{Time: svc.clk.Now(), Valid: true},
+			ID:       sessionID,
+		}); err != nil {
+			return err
+		}
+
+		return svc.close(sessionID, s)
+	})
+}
+
+func (s *state) checkOpen(sessionID string) error {
+	if s.closed {
+		return fmt.Errorf("%w: session %s is closed", internal.ErrInvalidInput, sessionID)
+	}
+
+	return nil
+}
+
+// close stops timers, then disconnects users of this replica after a final event.
+// It must be called with the session state locked.
+func (svc *Service) close(sessionID string, s *state) error {
+	slog.Info("Closing session", slog.String(internal.LogKeySession, sessionID))
+
+	if err := svc.stopCountdown(sessionID, s); err != nil {
+		return err
+	}
+
+	svc.stopTimer(s)
+
+	if err := svc.ntf.notifyClosed(sessionID, s); err != nil {
+		return err
+	}
+
+	s.closed = true
+
+	for i, res := range s.Results {
+		if res.local() {
+			s.closeEvents(res.events)
+
+			s.Results[i].events = nil
+		}
+
+		s.Results[i].inactive = true
+	}
+
+	return nil
+}
@@ -0,0 +1,151 @@
+package live
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/MartyHub/size-it/internal"
+	"github.com/MartyHub/size-it/internal/db/sqlc"
+)
+
+// countdown delays the automatic reveal of votes, so that users can still change their mind.
+type countdown struct {
+	end       time.Time
+	stop      chan struct{}
+	Remaining int
+}
+
+func (cd *countdown) update(now time.Time) {
+	cd.Remaining = int(math.Ceil(cd.end.Sub(now).Seconds()))
+}
+
+// autoReveal shows sizings once every active user has voted, if enabled for the session.
+// It must be called with the session state locked.
+func (svc *Service) autoReveal(sessionID string, s *state) error {
+	if !s.AutoReveal || s.Show || !s.allVoted() {
+		return svc.stopCountdown(sessionID, s)
+	}
+
+	if s.AutoRevealDelay <= 0 {
+		s.Show = true
+
+		return svc.ntf.notifyResults(sessionID, s)
+	}
+
+	if s.Countdown != nil {
+		return nil
+	}
+
+	slog.Info("Starting reveal countdown",
+		slog.String(internal.LogKeySession, sessionID),
+		slog.String("delay", s.AutoRevealDelay.String()),
+	)
+
+	svc.startCountdown(sessionID, s, svc.clk.Now().Add(s.AutoRevealDelay))
+
+	return svc.ntf.notifyCountdown(sessionID, s)
+}
+
+// syncCountdown follows the reveal countdown of a snapshot, possibly started by another replica.
+// It must be called with the session state locked.
+func (svc *Service) syncCountdown(sessionID string, s *state, revealAt time.Time) error {
+	if revealAt.IsZero() {
+		return svc.stopCountdown(sessionID, s)
+	}
+
+	if s.Countdown != nil && s.Countdown.end.Equal(revealAt) {
+		return nil
+	}
+
+	if err := svc.stopCountdown(sessionID, s); err != nil {
+		return err
+	}
+
+	svc.startCountdown(sessionID, s, revealAt)
+
+	return svc.ntf.notifyCountdown(sessionID, s)
+}
+
+// startCountdown must be called with the session state locked.
+func (svc *Service) startCountdown(sessionID string, s *state, end time.Time) {
+	s.Countdown = &countdown{
+		end:  end,
+		stop: make(chan struct{}),
+	}
+	s.Countdown.update(svc.clk.Now())
+
+	go svc.runCountdown(sessionID, s, s.Countdown, svc.clk.NewTicker(time.Second))
+}
+
+// stopCountdown cancels a pending automatic reveal.
+// It must be called with the session state locked.
+func (svc *Service) stopCountdown(sessionID string, s *state) error {
+	if s.Countdown == nil {
+		return nil
+	}
+
+	slog.Info("Stopping reveal countdown", slog.String(internal.LogKeySession, sessionID))
+
+	close(s.Countdown.stop)
+
+	s.Countdown = nil
+
+	return svc.ntf.notifyCountdown(sessionID, s)
+}
+
+func (svc *Service) runCountdown(sessionID string, s *state, cd *countdown, ticker internal.Ticker) {
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-svc.done:
+			return
+		case <-cd.stop:
+			return
+		case <-ticker.C():
+			done, err := svc.tickCountdown(sessionID, s, cd)
+			if err != nil {
+				internal.LogError("Failed to update reveal countdown", err)
+			}
+
+			if done {
+				return
+			}
+		}
+	}
+}
+
+func (svc *Service) tickCountdown(sessionID string, s *state, cd *countdown) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer svc.flush(sessionID, s)
+
+	if s.Countdown != cd {
+		// stopped in the meantime
+		return true, nil
+	}
+
+	cd.update(svc.clk.Now())
+
+	if cd.Remaining > 0 {
+		return false, svc.ntf.notifyCountdown(sessionID, s)
+	}
+
+	return true, svc.apply(context.Background(), sessionID, s, func(_ *sqlc.Queries) error {
+		if s.Countdown != cd {
+			// stopped by another replica in the meantime
+			return nil
+		}
+
+		s.Countdown = nil
+		s.Show = true
+
+		if err := svc.ntf.notifyCountdown(sessionID, s); err != nil {
+			return err
+		}
+
+		return svc.ntf.notifyResults(sessionID, s)
+	})
+}
@@ -0,0 +1,82 @@
+package live
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MartyHub/size-it/internal"
+)
+
+// startCountdown makes alice and bob vote, with votes revealed automatically after a delay.
+func startCountdown(t *testing.T, clk internal.Clock) (*Service, chan Event) {
+	t.Helper()
+
+	svc := newTestService(t, clk)
+	ctx := context.Background()
+	s := svc.stateBySessionID[testSessionID]
+
+	s.AutoReveal = true
+	s.AutoRevealDelay = 2 * time.Second
+
+	events := join(t, svc, alice, testBufferSize)
+
+	join(t, svc, bob, testBufferSize)
+
+	for _, usr := range []internal.User{alice, bob} {
+		if err := svc.SetSizingValue(ctx, testSessionID, 1, "1", usr); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := next(t, events, "countdown"); !strings.HasSuffix(string(got.Data), " 2") {
+		t.Errorf("countdown = %s, want 2", got.Data)
+	}
+
+	return svc, events
+}
+
+func TestService_autoReveal_countdown(t *testing.T) {
+	clk := internal.NewFixedClock(time.Now())
+	svc, events := startCountdown(t, clk)
+
+	if got := tick(t, clk, events, "countdown"); !strings.HasSuffix(got, " 1") {
+		t.Errorf("countdown = %s, want 1", got)
+	}
+
+	if shown(svc) {
+		t.Error("votes revealed before the end of the countdown")
+	}
+
+	tick(t, clk, events, "results")
+
+	if !shown(svc) {
+		t.Error("votes not revealed by the end of the countdown")
+	}
+}
+
+func TestService_autoReveal_stopped(t *testing.T) {
+	clk := internal.NewFixedClock(time.Now())
+	svc, events := startCountdown(t, clk)
+
+	tick(t, clk, events, "countdown")
+
+	// bob changes his mind
+	if err := svc.SetSizingValue(context.Background(), testSessionID, 1, "﹖", bob); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := svc.Reveal(context.Background(), testSessionID, false, alice); err != nil {
+		t.Fatal(err)
+	}
+
+	next(t, events, "countdown")
+
+	clk.Advance(5 * time.Second)
+	none(t, events, "countdown")
+
+	if shown(svc) {
+		t.Error("votes revealed by a stopped countdown")
+	}
+}
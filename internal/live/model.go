@@ -3,55 +3,93 @@ package live
 import (
 	"fmt"
 	"io"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/MartyHub/size-it/internal"
+	"github.com/MartyHub/size-it/internal/deck"
+	"github.com/MartyHub/size-it/internal/pubsub"
 )
 
-const (
-	SizingTypeStoryPoints = "STORY_POINTS"
-	SizingTypeTShirt      = "T_SHIRT"
-)
-
-var (
-	SizingValueStoryPoints = []string{"1", "2", "3", "5", "8", "13", "20", "40", "﹖"} //nolint:gochecknoglobals
-	SizingValueTShirt      = []string{"XS", "S", "M", "L", "XL", "XXL", "﹖"}          //nolint:gochecknoglobals
-)
+// maxSentEvents is the number of events kept for each active user, to be sent again if they reconnect.
+// It exceeds the events buffered for users, beyond which they reconnect without resuming anyway.
+const maxSentEvents = 64
 
 type (
+	// Event is identified by the epoch of the session state, and a sequence number increasing within it.
 	Event struct {
+		ID   string
 		Kind string
 		Data []byte
 	}
 
 	state struct {
-		mu      sync.Mutex
-		Ticket  *ticket
-		History []ticket
-		Results []result
-		Show    bool
-		Team    string
+		mu              sync.Mutex
+		closed          bool
+		deactivating    bool
+		dropped         bool
+		epoch           string
+		facilitatorID   string
+		lastSeq         uint64
+		outbox          []delivery
+		pending         []pubsub.Event
+		removed         map[string]time.Time
+		sent            map[string]*sentEvents
+		AutoReveal      bool
+		AutoRevealDelay time.Duration
+		Backlog         []BacklogItem
+		Countdown       *countdown
+		Decks           []deck.Deck
+		Ticket          *ticket
+		History         []ticket
+		Results         []result
+		Show            bool
+		Team            string
+		Timer           *timer
 	}
 
 	ticket struct {
-		ID          int64
-		Summary     string
-		URL         string
-		SizingType  string
-		SizingValue string
+		ID          int64  `json:"id,omitempty"`
+		Summary     string `json:"summary"`
+		URL         string `json:"url"`
+		DeckID      int64  `json:"deckId"`
+		SizingValue string `json:"sizingValue"`
+		Round       int    `json:"round"`
 	}
 
 	result struct {
 		events          chan Event
 		inactive        bool
 		maxInactiveTime time.Time
+		replica         string
+		votedAt         time.Time
 		User            internal.User
 		Sizing          string
 	}
+
+	// sentEvents are the last events sent to a user, sharing their data with the other users.
+	sentEvents struct {
+		events []sentEvent
+		// evicted is the sequence number of the last event dropped to make room for newer ones
+		evicted uint64
+	}
+
+	sentEvent struct {
+		seq   uint64
+		event Event
+	}
 )
 
 func (evt Event) Write(w io.Writer) error {
+	if evt.ID != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", evt.ID); err != nil {
+			return err
+		}
+	}
+
 	if _, err := fmt.Fprintf(w, "event: %s\n", evt.Kind); err != nil {
 		return err
 	}
@@ -63,6 +101,29 @@ func (evt Event) Write(w io.Writer) error {
 	return nil
 }
 
+// Deck returns the deck used to size the current ticket.
+func (s *state) Deck() deck.Deck {
+	for _, dck := range s.Decks {
+		if dck.ID == s.Ticket.DeckID {
+			return dck
+		}
+	}
+
+	return deck.Deck{}
+}
+
+func (s *state) IsFacilitator(usr internal.User) bool {
+	return s.facilitatorID == usr.ID
+}
+
+func (s *state) checkFacilitator(sessionID string, usr internal.User) error {
+	if !s.IsFacilitator(usr) {
+		return fmt.Errorf("%w: %s is not facilitator of session %s", internal.ErrUnauthorized, usr.Name, sessionID)
+	}
+
+	return nil
+}
+
 func (s *state) SizingValue(usr internal.User) string {
 	for _, res := range s.Results {
 		if res.User.Equals(usr) {
@@ -73,15 +134,31 @@ func (s *state) SizingValue(usr internal.User) string {
 	return ""
 }
 
-func (s *state) userJoin(usr internal.User, events chan Event) {
+// chosenValue returns the value to save for the ticket: the suggested card once votes are revealed,
+// or else the card chosen by usr, if any.
+func (s *state) chosenValue(usr internal.User) string {
+	if s.Show {
+		if res := s.Stats().Suggested; res != "" {
+			return res
+		}
+	}
+
+	return s.SizingValue(usr)
+}
+
+func (s *state) userJoin(usr internal.User, events chan Event, replicaID string) {
 	for i, res := range s.Results {
 		if res.User.Equals(usr) {
-			close(res.events)
+			if res.local() {
+				s.closeEvents(res.events)
+			}
 
 			s.Results[i] = result{
-				events: events,
-				User:   usr,
-				Sizing: res.Sizing,
+				events:  events,
+				replica: replicaID,
+				votedAt: res.votedAt,
+				User:    usr,
+				Sizing:  res.Sizing,
 			}
 
 			return
@@ -89,27 +166,71 @@ func (s *state) userJoin(usr internal.User, events chan Event) {
 	}
 
 	s.Results = append(s.Results, result{
-		User:   usr,
-		events: events,
+		User:    usr,
+		events:  events,
+		replica: replicaID,
 	})
 }
 
+func (s *state) switchDeck(deckID int64) {
+	s.Ticket.DeckID = deckID
+	s.Ticket.SizingValue = ""
+
+	for i := range s.Results {
+		s.Results[i].Sizing = ""
+		s.Results[i].votedAt = time.Time{}
+	}
+}
+
 func (s *state) reset() {
 	s.Ticket.ID = 0
 	s.Ticket.Summary = ""
 	s.Ticket.URL = ""
+	s.Ticket.Round = 1
+
+	s.clearSizings()
+}
+
+func (s *state) newRound() {
+	s.Ticket.Round++
+
+	s.clearSizings()
+}
+
+func (s *state) clearSizings() {
 	s.Ticket.SizingValue = ""
 
 	s.Show = false
 
 	for i := range s.Results {
 		s.Results[i].Sizing = ""
+		s.Results[i].votedAt = time.Time{}
+	}
+}
+
+// allVoted reports whether every active user has voted.
+func (s *state) allVoted() bool {
+	voters := 0
+
+	for _, res := range s.Results {
+		if res.inactive {
+			continue
+		}
+
+		if res.Sizing == "" {
+			return false
+		}
+
+		voters++
 	}
+
+	return voters > 0
 }
 
+// empty reports whether no active user is connected to this replica.
 func (s *state) empty() bool {
 	for _, res := range s.Results {
-		if !res.inactive {
+		if !res.inactive && res.local() {
 			return false
 		}
 	}
@@ -121,6 +242,11 @@ func (tck ticket) New() bool {
 	return tck.ID == 0
 }
 
+// Revoted reports whether the ticket has been voted more than once.
+func (tck ticket) Revoted() bool {
+	return tck.Round > 1
+}
+
 func (tck ticket) valid() bool {
 	return tck.Summary != "" && tck.SizingValue != ""
 }
@@ -128,3 +254,83 @@ func (tck ticket) valid() bool {
 func (res result) Hide() bool {
 	return res.inactive
 }
+
+// local reports whether the user is connected to this replica.
+func (res result) local() bool {
+	return res.events != nil
+}
+
+// send identifies the event, and keeps it to be sent again if the user reconnects.
+// It returns false, without waiting, if the events of the user are full.
+func (s *state) send(d delivery, evt Event) bool {
+	s.lastSeq++
+
+	evt.ID = s.epoch + "-" + strconv.FormatUint(s.lastSeq, 10)
+
+	select {
+	case d.events <- evt:
+	default:
+		return false
+	}
+
+	if s.sent == nil {
+		s.sent = make(map[string]*sentEvents)
+	}
+
+	sent, found := s.sent[d.userID]
+	if !found {
+		sent = &sentEvents{}
+		s.sent[d.userID] = sent
+	}
+
+	sent.events = append(sent.events, sentEvent{seq: s.lastSeq, event: evt})
+
+	if len(sent.events) > maxSentEvents {
+		sent.evicted = sent.events[0].seq
+		sent.events = slices.Delete(sent.events, 0, 1)
+	}
+
+	return true
+}
+
+// forgetSentEvents drops the events sent to users who can't resume anymore, once inactive or gone.
+func (s *state) forgetSentEvents() {
+	for userID := range s.sent {
+		if !slices.ContainsFunc(s.Results, func(res result) bool { return !res.inactive && res.User.ID == userID }) {
+			delete(s.sent, userID)
+		}
+	}
+}
+
+// missedEvents returns the events sent to usr after the given one.
+// It returns false if the event is unknown, or too old to tell which events have been missed.
+func (s *state) missedEvents(usr internal.User, lastEventID string) ([]Event, bool) {
+	epoch, seqText, found := strings.Cut(lastEventID, "-")
+	if !found || epoch != s.epoch {
+		return nil, false
+	}
+
+	seq, err := strconv.ParseUint(seqText, 10, 64)
+	if err != nil || seq > s.lastSeq {
+		return nil, false
+	}
+
+	sent, found := s.sent[usr.ID]
+	if !found {
+		return nil, true
+	}
+
+	if seq < sent.evicted {
+		return nil, false
+	}
+
+	var res []Event
+
+	for _, evt := range sent.events {
+		if evt.seq > seq {
+			res = append(res, evt.event)
+		}
+	}
+
+	return res, true
+}
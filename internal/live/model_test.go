@@ -0,0 +1,45 @@
+package live
+
+import (
+	"testing"
+
+	"github.com/MartyHub/size-it/internal/deck"
+)
+
+// testState returns a state sized with a deck of the given cards, weighted by their position unless named "﹖".
+func testState(values ...string) *state {
+	cards := make([]deck.Card, len(values))
+
+	for i, value := range values {
+		cards[i].Value = value
+
+		if value != "﹖" {
+			weight := float64(i + 1)
+			cards[i].Weight = &weight
+		}
+	}
+
+	return &state{
+		Decks:  []deck.Deck{{ID: 1, Name: "Test", Cards: cards}},
+		Ticket: &ticket{DeckID: 1, Round: 1},
+	}
+}
+
+func TestState_chosenValue(t *testing.T) {
+	s := testState("1", "2", "3")
+	s.Results = []result{
+		{User: alice, Sizing: "1"},
+		{User: bob, Sizing: "3"},
+	}
+
+	// unrevealed votes are not disclosed
+	if got := s.chosenValue(alice); got != "1" {
+		t.Errorf("hidden chosenValue = %s, want 1", got)
+	}
+
+	s.Show = true
+
+	if got := s.chosenValue(alice); got != "2" {
+		t.Errorf("shown chosenValue = %s, want 2", got)
+	}
+}
@@ -3,105 +3,219 @@ package live
 import (
 	"bytes"
 	"log/slog"
+	"slices"
+	"time"
 
 	"github.com/MartyHub/size-it/internal"
+	"github.com/MartyHub/size-it/internal/metrics"
 	"github.com/labstack/echo/v4"
 )
 
+// renderBuckets are suited to the rendering of components, in seconds.
+var renderBuckets = []float64{.0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1} //nolint:gochecknoglobals
+
 type (
 	notifier struct {
 		path string
 
 		clk internal.Clock
 		rdr echo.Renderer
+
+		broadcasts      *metrics.Counter
+		renderDurations *metrics.Histogram
 	}
 
 	notifyUserFunc func(res result) bool
+
+	// delivery is an event for a user of this replica, rendered and sent by Service.flush.
+	// The events of the user are closed instead if render is nil.
+	delivery struct {
+		events chan Event
+		userID string
+		kind   string
+		render func() ([]byte, error)
+	}
 )
 
 func (ntf *notifier) notifyTicket(sessionID string, s *state, notifyUser notifyUserFunc) error {
 	return ntf.notify(sessionID, "ticket", "components/ticket.gohtml", s, notifyUser)
 }
 
-func (ntf *notifier) notifyTabs(sessionID string, s *state, notifyUser notifyUserFunc, renderByUser bool) error {
-	if renderByUser {
-		return ntf.notifyByUser(sessionID, "tabs", "components/tabs.gohtml", s, notifyUser)
-	}
+func (ntf *notifier) notifyTabs(sessionID string, s *state, notifyUser notifyUserFunc) error {
+	return ntf.notifyByUser(sessionID, "tabs", "components/tabs.gohtml", s, notifyUser)
+}
 
-	return ntf.notify(sessionID, "tabs", "components/tabs.gohtml", s, notifyUser)
+func (ntf *notifier) notifyControls(sessionID string, s *state, notifyUser notifyUserFunc) error {
+	return ntf.notifyByUser(sessionID, "controls", "components/controls.gohtml", s, notifyUser)
 }
 
 func (ntf *notifier) notifyHistory(sessionID string, s *state, notifyUser notifyUserFunc) error {
 	return ntf.notify(sessionID, "history", "components/history.gohtml", s, notifyUser)
 }
 
+func (ntf *notifier) notifyBacklog(sessionID string, s *state, notifyUser notifyUserFunc) error {
+	return ntf.notifyByUser(sessionID, "backlog", "components/backlog.gohtml", s, notifyUser)
+}
+
 func (ntf *notifier) notifyResults(sessionID string, s *state) error {
-	return ntf.notify(sessionID, "results", "components/results.gohtml", s, allActiveUsers)
+	return ntf.notifyByUser(sessionID, "results", "components/results.gohtml", s, allActiveUsers)
+}
+
+func (ntf *notifier) notifyCountdown(sessionID string, s *state) error {
+	return ntf.notify(sessionID, "countdown", "components/countdown.gohtml", s, allActiveUsers)
+}
+
+func (ntf *notifier) notifyTimer(sessionID string, s *state, notifyUser notifyUserFunc) error {
+	return ntf.notify(sessionID, "timer", "components/timer.gohtml", s, notifyUser)
+}
+
+// notifyRemoved tells a removed user why the session stopped, before closing their events.
+func (ntf *notifier) notifyRemoved(sessionID string, s *state, usr internal.User) error {
+	return ntf.notify(sessionID, "disconnected", "components/removed.gohtml", s, includeUser(usr))
+}
+
+// notifyClosed tells users that the session ended, before closing their events.
+func (ntf *notifier) notifyClosed(sessionID string, s *state) error {
+	return ntf.notify(sessionID, "disconnected", "components/closed.gohtml", s, allActiveUsers)
 }
 
 func (ntf *notifier) notify(sessionID, kind, template string, s *state, notifyUser notifyUserFunc) error {
 	slog.Info("Broadcasting...", slog.String(internal.LogKeyEvent, kind))
+	ntf.broadcasts.Inc(kind)
+
+	var (
+		data     []byte
+		err      error
+		rendered bool
+	)
+
+	// rendered once for every user
+	render := func() ([]byte, error) {
+		if !rendered {
+			data, err = ntf.render(template, map[string]any{
+				"path":            ntf.path,
+				"sessionID":       sessionID,
+				"state":           s,
+				"userSizingValue": "",
+			})
+			rendered = true
+		}
 
-	var buf bytes.Buffer
-
-	data := map[string]any{
-		"path":                   ntf.path,
-		"sessionID":              sessionID,
-		"sizingValueStoryPoints": SizingValueStoryPoints,
-		"sizingValueTShirt":      SizingValueTShirt,
-		"state":                  s,
-		"userSizingValue":        "",
+		return data, err
 	}
 
-	if err := ntf.rdr.Render(&buf, template, data, nil); err != nil {
-		return err
+	for _, res := range s.Results {
+		if res.local() && notifyUser(res) {
+			s.queue(res, kind, render)
+		}
 	}
 
-	evt := Event{
-		Kind: kind,
-		Data: bytes.ReplaceAll(buf.Bytes(), []byte{'\n'}, []byte{}),
-	}
+	return nil
+}
+
+func (ntf *notifier) notifyByUser(sessionID, kind, template string, s *state, notifyUser notifyUserFunc) error {
+	slog.Info("Broadcasting by user...", slog.String(internal.LogKeyEvent, kind))
+	ntf.broadcasts.Inc(kind)
 
 	for _, res := range s.Results {
-		if notifyUser(res) {
-			res.events <- evt
+		if !res.local() || !notifyUser(res) {
+			continue
 		}
+
+		usr, sizing := res.User, res.Sizing
+
+		s.queue(res, kind, func() ([]byte, error) {
+			return ntf.render(template, map[string]any{
+				"path":            ntf.path,
+				"sessionID":       sessionID,
+				"state":           s,
+				"user":            usr,
+				"userSizingValue": sizing,
+			})
+		})
 	}
 
 	return nil
 }
 
-func (ntf *notifier) notifyByUser(sessionID, kind, template string, s *state, notifyUser notifyUserFunc) error {
+func (ntf *notifier) render(template string, data map[string]any) ([]byte, error) {
 	var buf bytes.Buffer
 
-	slog.Info("Broadcasting by user...", slog.String(internal.LogKeyEvent, kind))
+	start := time.Now()
+	defer func() {
+		ntf.renderDurations.Observe(time.Since(start).Seconds(), template)
+	}()
 
-	for _, res := range s.Results {
-		if !notifyUser(res) {
+	if err := ntf.rdr.Render(&buf, template, data, nil); err != nil {
+		return nil, err
+	}
+
+	return bytes.ReplaceAll(buf.Bytes(), []byte{'\n'}, []byte{}), nil
+}
+
+// queue delays the event for res until the state is flushed.
+func (s *state) queue(res result, kind string, render func() ([]byte, error)) {
+	s.outbox = append(s.outbox, delivery{
+		events: res.events,
+		userID: res.User.ID,
+		kind:   kind,
+		render: render,
+	})
+}
+
+// closeEvents closes the events of a user once the events queued before are flushed.
+func (s *state) closeEvents(events chan Event) {
+	s.outbox = append(s.outbox, delivery{events: events})
+}
+
+// flush renders and sends the queued events.
+// Users not reading their events fast enough are disconnected, until they join again.
+// It must be called with the session state locked.
+func (svc *Service) flush(sessionID string, s *state) {
+	outbox := s.outbox
+	closed := make(map[chan Event]bool)
+
+	s.outbox = nil
+
+	for _, d := range outbox {
+		if closed[d.events] {
 			continue
 		}
 
-		data := map[string]any{
-			"path":                   ntf.path,
-			"sessionID":              sessionID,
-			"sizingValueStoryPoints": SizingValueStoryPoints,
-			"sizingValueTShirt":      SizingValueTShirt,
-			"state":                  s,
-			"user":                   res.User,
-			"userSizingValue":        res.Sizing,
+		if d.render == nil {
+			close(d.events)
+
+			closed[d.events] = true
+
+			continue
 		}
 
-		buf.Reset()
+		data, err := d.render()
+		if err != nil {
+			internal.LogError("Failed to render "+d.kind, err)
+
+			continue
+		}
 
-		if err := ntf.rdr.Render(&buf, template, data, nil); err != nil {
-			return err
+		if s.send(d, Event{Kind: d.kind, Data: data}) {
+			continue
 		}
 
-		res.events <- Event{
-			Kind: kind,
-			Data: bytes.ReplaceAll(buf.Bytes(), []byte{'\n'}, []byte{}),
+		slog.Warn("Disconnecting slow user",
+			slog.String(internal.LogKeySession, sessionID),
+			slog.String(internal.LogKeyEvent, d.kind),
+		)
+
+		close(d.events)
+
+		closed[d.events] = true
+
+		if i := slices.IndexFunc(s.Results, func(res result) bool { return res.events == d.events }); i >= 0 {
+			s.Results[i].events = nil
+
+			svc.leave(sessionID, s, i)
 		}
 	}
 
-	return nil
+	s.forgetSentEvents()
 }
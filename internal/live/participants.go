@@ -0,0 +1,84 @@
+package live
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+
+	"github.com/MartyHub/size-it/internal"
+)
+
+// Participants returns the number of users connected to each of the given sessions.
+// Sessions not held by this replica are counted from their last saved state, ignoring replicas not running anymore.
+func (svc *Service) Participants(ctx context.Context, sessionIDs []string) (map[string]int, error) {
+	res := make(map[string]int, len(sessionIDs))
+
+	var replicas []string
+
+	for _, sessionID := range sessionIDs {
+		if count, found := svc.localParticipants(sessionID); found {
+			res[sessionID] = count
+
+			continue
+		}
+
+		data, err := svc.store.Load(ctx, sessionID)
+		if err != nil {
+			if errors.Is(err, internal.ErrNotFound) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		var snp snapshot
+
+		if err = json.Unmarshal(data, &snp); err != nil {
+			return nil, err
+		}
+
+		if snp.Closed {
+			continue
+		}
+
+		if replicas == nil {
+			if replicas, err = svc.bus.Replicas(ctx); err != nil {
+				return nil, err
+			}
+
+			replicas = append(replicas, svc.replicaID)
+		}
+
+		for _, r := range snp.Results {
+			if !r.Inactive && slices.Contains(replicas, r.Replica) {
+				res[sessionID]++
+			}
+		}
+	}
+
+	return res, nil
+}
+
+func (svc *Service) localParticipants(sessionID string) (int, bool) {
+	svc.mu.RLock()
+	s, found := svc.stateBySessionID[sessionID]
+	svc.mu.RUnlock()
+
+	if !found {
+		return 0, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res := 0
+
+	for _, r := range s.Results {
+		if !r.inactive {
+			res++
+		}
+	}
+
+	return res, true
+}
@@ -0,0 +1,48 @@
+package live
+
+import (
+	"github.com/MartyHub/size-it/internal/pubsub"
+)
+
+// Sizing is the data of ticket.sized and votes.revealed events.
+type Sizing struct {
+	Ticket ticket     `json:"ticket"`
+	Deck   string     `json:"deck"`
+	Weight *float64   `json:"weight,omitempty"`
+	Votes  []VoteView `json:"votes"`
+	Stats  stats      `json:"stats"`
+}
+
+// publish queues an event, published by apply once the state is saved.
+// It must be called with the session state locked.
+func (svc *Service) publish(sessionID string, s *state, eventType string) {
+	sizing := Sizing{
+		Ticket: *s.Ticket,
+		Deck:   s.Deck().Name,
+		Stats:  s.Stats(),
+	}
+
+	if card, found := s.Deck().Card(s.Ticket.SizingValue); found {
+		sizing.Weight = card.Weight
+	}
+
+	for _, r := range s.Results {
+		if r.inactive || r.Sizing == "" {
+			continue
+		}
+
+		sizing.Votes = append(sizing.Votes, VoteView{
+			User:  r.User,
+			Voted: true,
+			Value: r.Sizing,
+		})
+	}
+
+	s.pending = append(s.pending, pubsub.Event{
+		Type:      eventType,
+		Team:      s.Team,
+		SessionID: sessionID,
+		CreatedAt: svc.clk.Now(),
+		Data:      sizing,
+	})
+}
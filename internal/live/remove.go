@@ -0,0 +1,95 @@
+package live
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/MartyHub/size-it/internal"
+	"github.com/MartyHub/size-it/internal/db/sqlc"
+)
+
+// RemoveUser disconnects a participant and drops their vote, facilitator only.
+// The participant can't join the session again until the rejoin delay elapsed.
+func (svc *Service) RemoveUser(ctx context.Context, sessionID, userID string, usr internal.User) error {
+	return svc.update(ctx, sessionID, func(s *state, _ *sqlc.Queries) error {
+		if err := s.checkFacilitator(sessionID, usr); err != nil {
+			return err
+		}
+
+		if userID == usr.ID {
+			return fmt.Errorf("%w: facilitator %s can't remove themselves", internal.ErrInvalidInput, usr.Name)
+		}
+
+		i := slices.IndexFunc(s.Results, func(res result) bool { return res.User.ID == userID })
+		if i < 0 {
+			return fmt.Errorf("%w: user %s in session %s", internal.ErrNotFound, userID, sessionID)
+		}
+
+		target := s.Results[i].User
+
+		slog.Info("Removing user",
+			slog.String(internal.LogKeySession, sessionID),
+			slog.String(internal.LogKeyUser, target.Name),
+			slog.String("by", usr.Name),
+		)
+
+		if err := svc.ntf.notifyRemoved(sessionID, s, target); err != nil {
+			return err
+		}
+
+		now := svc.clk.Now()
+
+		s.purgeRemoved(now)
+
+		if s.removed == nil {
+			s.removed = make(map[string]time.Time)
+		}
+
+		s.removed[userID] = now.Add(svc.rejoinDelay)
+		s.dropRemoved()
+
+		if err := svc.ntf.notifyResults(sessionID, s); err != nil {
+			return err
+		}
+
+		return svc.autoReveal(sessionID, s)
+	})
+}
+
+// checkRemoved returns internal.ErrUnauthorized if usr has been removed from the session recently.
+func (s *state) checkRemoved(sessionID string, usr internal.User, now time.Time) error {
+	if until, found := s.removed[usr.ID]; found && now.Before(until) {
+		return fmt.Errorf("%w: %s has been removed from session %s until %s",
+			internal.ErrUnauthorized, usr.Name, sessionID, until.Format(time.TimeOnly))
+	}
+
+	delete(s.removed, usr.ID)
+
+	return nil
+}
+
+// purgeRemoved forgets users who can join the session again.
+func (s *state) purgeRemoved(now time.Time) {
+	maps.DeleteFunc(s.removed, func(_ string, until time.Time) bool {
+		return !now.Before(until)
+	})
+}
+
+// dropRemoved deletes the results of removed users, closing the events of those connected to this replica.
+func (s *state) dropRemoved() {
+	s.Results = slices.DeleteFunc(s.Results, func(res result) bool {
+		if _, found := s.removed[res.User.ID]; !found {
+			return false
+		}
+
+		if res.local() {
+			s.closeEvents(res.events)
+		}
+
+		return true
+	})
+}
@@ -2,21 +2,26 @@ package live
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/MartyHub/size-it/internal"
 	"github.com/MartyHub/size-it/internal/db"
 	"github.com/MartyHub/size-it/internal/db/sqlc"
+	"github.com/MartyHub/size-it/internal/deck"
+	"github.com/MartyHub/size-it/internal/metrics"
+	"github.com/MartyHub/size-it/internal/pubsub"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/labstack/echo/v4"
+	"github.com/oklog/ulid/v2"
 )
 
-const (
-	defaultSizingType = SizingTypeStoryPoints
-	maxBucketSize     = 5
-)
+const maxBucketSize = 5
 
 var allActiveUsers notifyUserFunc = func(res result) bool { //nolint:gochecknoglobals
 	return !res.inactive
@@ -26,11 +31,18 @@ type Service struct {
 	done             <-chan struct{}
 	maxInactiveTime  time.Duration
 	mu               sync.RWMutex
+	rejoinDelay      time.Duration
 	stateBySessionID map[string]*state
-
-	clk  internal.Clock
-	ntf  *notifier
-	repo *db.Repository
+	replicaID        string
+
+	bus      Bus
+	clk      internal.Clock
+	decks    *deck.Service
+	ntf      *notifier
+	pubsub   *pubsub.Bus
+	repo     *db.Repository
+	resolver SummaryResolver
+	store    Store
 }
 
 func NewService(
@@ -39,68 +51,141 @@ func NewService(
 	clk internal.Clock,
 	rdr echo.Renderer,
 	repo *db.Repository,
+	reg *metrics.Registry,
+	ps *pubsub.Bus,
+	store Store,
+	bus Bus,
 ) *Service {
 	res := &Service{
+		bus:             bus,
 		clk:             clk,
+		decks:           deck.NewService(clk, repo),
 		done:            done,
 		maxInactiveTime: cfg.MaxInactiveTime,
 		ntf: &notifier{
 			path: cfg.Path,
 			clk:  clk,
 			rdr:  rdr,
+			broadcasts: reg.Counter(
+				"size_it_broadcasts_total",
+				"Number of broadcasts to users of live sessions, by event.",
+				"event",
+			),
+			renderDurations: reg.Histogram(
+				"size_it_render_duration_seconds",
+				"Duration of template rendering for broadcasts, by template.",
+				renderBuckets,
+				"template",
+			),
 		},
+		pubsub:           ps,
+		rejoinDelay:      cfg.RejoinDelay,
+		replicaID:        ulid.Make().String(),
 		repo:             repo,
 		stateBySessionID: make(map[string]*state),
+		store:            store,
 	}
 
+	reg.GaugeFunc("size_it_live_sessions", "Number of live sessions held by this replica.", res.sessionCount)
+
+	go res.startListening()
 	go res.startRemoveEmptySessions(cfg.EmptySessionsTick)
 
 	return res
 }
 
-func (svc *Service) Join(ctx context.Context, sessionID string, usr internal.User, events chan Event) error {
-	var err error
+func (svc *Service) sessionCount() float64 {
+	svc.mu.RLock()
+	defer svc.mu.RUnlock()
 
-	svc.mu.Lock()
-	defer svc.mu.Unlock()
+	return float64(len(svc.stateBySessionID))
+}
 
-	s, found := svc.stateBySessionID[sessionID]
-	if !found {
-		s, err = svc.init(ctx, sessionID)
-		if err != nil {
-			return err
-		}
+// Join connects the user to the session, sending every component to events.
+// If the user reconnects after lastEventID, only the events missed since are sent again, if they are still known.
+func (svc *Service) Join(
+	ctx context.Context,
+	sessionID string,
+	usr internal.User,
+	events chan Event,
+	lastEventID string,
+) error {
+	s, err := svc.lockedState(ctx, sessionID)
+	if err != nil {
+		return err
 	}
 
+	defer s.mu.Unlock()
+
 	slog.Info("User joining session",
 		slog.String(internal.LogKeySession, sessionID),
 		slog.String(internal.LogKeyUser, usr.Name),
 	)
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	return svc.apply(ctx, sessionID, s, func(queries *sqlc.Queries) error {
+		if err := s.checkOpen(sessionID); err != nil {
+			return err
+		}
 
-	s.userJoin(usr, events)
+		if err := s.checkRemoved(sessionID, usr, svc.clk.Now()); err != nil {
+			return err
+		}
 
-	notifyUser := includeUser(usr)
+		// once deactivated, the user no longer received any event, so missed events are unknown
+		resumable := slices.ContainsFunc(s.Results, func(res result) bool {
+			return !res.inactive && res.User.Equals(usr)
+		})
 
-	if err = svc.ntf.notifyTicket(sessionID, s, notifyUser); err != nil {
-		return err
-	}
+		s.userJoin(usr, events, svc.replicaID)
 
-	if err = svc.ntf.notifyTabs(sessionID, s, notifyUser, false); err != nil {
-		return err
-	}
+		if s.facilitatorID == "" {
+			if err := svc.setFacilitator(ctx, queries, sessionID, s, usr); err != nil {
+				return err
+			}
+		}
 
-	if err = svc.ntf.notifyHistory(sessionID, s, notifyUser); err != nil {
-		return err
-	}
+		if missed, ok := s.missedEvents(usr, lastEventID); resumable && ok && len(missed) < cap(events) {
+			slog.Info("Sending missed events",
+				slog.String(internal.LogKeySession, sessionID),
+				slog.String(internal.LogKeyUser, usr.Name),
+				slog.Int("count", len(missed)),
+			)
 
-	if err = svc.ntf.notifyResults(sessionID, s); err != nil {
-		return err
-	}
+			for _, evt := range missed {
+				events <- evt
+			}
 
-	return nil
+			return svc.ntf.notifyResults(sessionID, s)
+		}
+
+		notifyUser := includeUser(usr)
+
+		if err := svc.ntf.notifyTicket(sessionID, s, notifyUser); err != nil {
+			return err
+		}
+
+		if err := svc.ntf.notifyControls(sessionID, s, notifyUser); err != nil {
+			return err
+		}
+
+		if err := svc.ntf.notifyTabs(sessionID, s, notifyUser); err != nil {
+			return err
+		}
+
+		if err := svc.ntf.notifyHistory(sessionID, s, notifyUser); err != nil {
+			return err
+		}
+
+		if err := svc.ntf.notifyTimer(sessionID, s, notifyUser); err != nil {
+			return err
+		}
+
+		if err := svc.ntf.notifyResults(sessionID, s); err != nil {
+			return err
+		}
+
+		return svc.autoReveal(sessionID, s)
+	})
 }
 
 func (svc *Service) Leave(sessionID string, usr internal.User) {
@@ -120,64 +205,462 @@ func (svc *Service) Leave(sessionID string, usr internal.User) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	for i, res := range s.Results {
-		if res.User.Equals(usr) {
-			s.Results[i].maxInactiveTime = svc.clk.Now().Add(svc.maxInactiveTime)
+	if i := slices.IndexFunc(s.Results, func(res result) bool { return res.User.Equals(usr) }); i >= 0 {
+		svc.leave(sessionID, s, i)
+	}
+}
 
-			go svc.startDeactivateUsers(sessionID, s)
+// leave deactivates the user of the given result once the inactivity delay is over, unless they join again
+// in the meantime, starting the deactivation of the users of the session if not started yet.
+// It must be called with the session state locked.
+func (svc *Service) leave(sessionID string, s *state, i int) {
+	s.Results[i].maxInactiveTime = svc.clk.Now().Add(svc.maxInactiveTime)
 
-			return
-		}
+	if !s.deactivating {
+		s.deactivating = true
+
+		go svc.startDeactivateUsers(sessionID, s, svc.clk.NewTicker(svc.maxInactiveTime))
 	}
 }
 
-func (svc *Service) UpdateTicket(sessionID, summary, url string, usr internal.User) error {
-	svc.mu.RLock()
-	defer svc.mu.RUnlock()
+// Attend registers the user as an active participant without events, like API clients,
+// deactivated once the inactivity delay is over unless they attend again.
+// Users connected to a replica are left unchanged.
+func (svc *Service) Attend(ctx context.Context, sessionID string, usr internal.User) error {
+	return svc.update(ctx, sessionID, func(s *state, queries *sqlc.Queries) error {
+		if err := s.checkRemoved(sessionID, usr, svc.clk.Now()); err != nil {
+			return err
+		}
 
-	s, found := svc.stateBySessionID[sessionID]
-	if !found {
-		return fmt.Errorf("%w: session %s", internal.ErrNotFound, sessionID)
-	}
+		i := slices.IndexFunc(s.Results, func(res result) bool { return res.User.Equals(usr) })
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
+		switch {
+		case i < 0:
+			s.Results = append(s.Results, result{User: usr})
+			i = len(s.Results) - 1
+		case s.Results[i].local() || (!s.Results[i].inactive && s.Results[i].replica != svc.replicaID):
+			return nil
+		}
 
-	s.Ticket.Summary = summary
-	s.Ticket.URL = url
+		joined := s.Results[i].inactive || s.Results[i].replica == ""
 
-	return svc.ntf.notifyTicket(sessionID, s, excludeUser(usr))
+		s.Results[i].inactive = false
+		s.Results[i].replica = svc.replicaID
+		s.Results[i].User = usr
+
+		svc.leave(sessionID, s, i)
+
+		if s.facilitatorID == "" {
+			if err := svc.setFacilitator(ctx, queries, sessionID, s, usr); err != nil {
+				return err
+			}
+		}
+
+		if !joined {
+			return nil
+		}
+
+		slog.Info("User attending session",
+			slog.String(internal.LogKeySession, sessionID),
+			slog.String(internal.LogKeyUser, usr.Name),
+		)
+
+		return svc.ntf.notifyResults(sessionID, s)
+	})
+}
+
+// UpdateTicket broadcasts the ticket to other users.
+// If its URL changed without a summary, the summary is then fetched in the background.
+func (svc *Service) UpdateTicket(ctx context.Context, sessionID, summary, url string, usr internal.User) error {
+	var (
+		resolve bool
+		team    string
+	)
+
+	if err := svc.update(ctx, sessionID, func(s *state, _ *sqlc.Queries) error {
+		resolve = svc.resolver != nil && summary == "" && url != "" && url != s.Ticket.URL
+		team = s.Team
+
+		s.Ticket.Summary = summary
+		s.Ticket.URL = url
+
+		return svc.ntf.notifyTicket(sessionID, s, excludeUser(usr))
+	}); err != nil {
+		return err
+	}
+
+	if resolve {
+		go svc.resolveSummary(sessionID, team, url)
+	}
+
+	return nil
 }
 
 func (svc *Service) AddTicketToHistory(ctx context.Context, sessionID string, usr internal.User) error {
+	return svc.update(ctx, sessionID, func(s *state, queries *sqlc.Queries) error {
+		if err := s.checkFacilitator(sessionID, usr); err != nil {
+			return err
+		}
+
+		s.Ticket.SizingValue = s.chosenValue(usr)
+
+		if !s.Ticket.valid() {
+			return nil
+		}
+
+		if err := svc.saveTicket(ctx, queries, sessionID, s); err != nil {
+			return err
+		}
+
+		svc.publish(sessionID, s, pubsub.TicketSized)
+
+		return svc.refreshHistory(ctx, queries, sessionID, s)
+	})
+}
+
+// Reveal shows or hides sizings, whatever their current visibility.
+func (svc *Service) Reveal(ctx context.Context, sessionID string, show bool, usr internal.User) error {
+	return svc.update(ctx, sessionID, func(s *state, _ *sqlc.Queries) error {
+		if err := s.checkFacilitator(sessionID, usr); err != nil {
+			return err
+		}
+
+		if err := svc.stopCountdown(sessionID, s); err != nil {
+			return err
+		}
+
+		s.Show = show
+
+		return svc.ntf.notifyResults(sessionID, s)
+	})
+}
+
+func (svc *Service) ToggleSizings(ctx context.Context, sessionID string, usr internal.User) error {
+	return svc.update(ctx, sessionID, func(s *state, _ *sqlc.Queries) error {
+		if err := s.checkFacilitator(sessionID, usr); err != nil {
+			return err
+		}
+
+		if err := svc.stopCountdown(sessionID, s); err != nil {
+			return err
+		}
+
+		s.Show = !s.Show
+
+		return svc.ntf.notifyResults(sessionID, s)
+	})
+}
+
+func (svc *Service) SwitchDeck(ctx context.Context, sessionID string, deckID int64, usr internal.User) error {
+	return svc.update(ctx, sessionID, func(s *state, queries *sqlc.Queries) error {
+		if err := s.checkFacilitator(sessionID, usr); err != nil {
+			return err
+		}
+
+		if !slices.ContainsFunc(s.Decks, func(dck deck.Deck) bool { return dck.ID == deckID }) {
+			return fmt.Errorf("%w: deck %d is not available in session %s", internal.ErrInvalidInput, deckID, sessionID)
+		}
+
+		if err := svc.stopCountdown(sessionID, s); err != nil {
+			return err
+		}
+
+		s.switchDeck(deckID)
+
+		if err := svc.ntf.notifyTabs(sessionID, s, allActiveUsers); err != nil {
+			return err
+		}
+
+		if err := svc.ntf.notifyResults(sessionID, s); err != nil {
+			return err
+		}
+
+		return svc.refreshHistory(ctx, queries, sessionID, s)
+	})
+}
+
+func (svc *Service) SetSizingValue(
+	ctx context.Context,
+	sessionID string,
+	deckID int64,
+	sizingValue string,
+	usr internal.User,
+) error {
+	return svc.update(ctx, sessionID, func(s *state, _ *sqlc.Queries) error {
+		dck := s.Deck()
+
+		if dck.ID != deckID || !dck.Contains(sizingValue) {
+			return fmt.Errorf("%w: card %s is not part of deck %s", internal.ErrInvalidInput, sizingValue, dck.Name)
+		}
+
+		i := slices.IndexFunc(s.Results, func(res result) bool { return res.User.Equals(usr) })
+		if i < 0 {
+			return fmt.Errorf("%w: %s must join session %s before voting", internal.ErrInvalidInput, usr.Name, sessionID)
+		}
+
+		s.Results[i].Sizing = sizingValue
+		s.Results[i].votedAt = svc.clk.Now()
+
+		if err := svc.ntf.notifyTabs(sessionID, s, includeUser(usr)); err != nil {
+			return err
+		}
+
+		if err := svc.ntf.notifyResults(sessionID, s); err != nil {
+			return err
+		}
+
+		return svc.autoReveal(sessionID, s)
+	})
+}
+
+func (svc *Service) ResetSession(ctx context.Context, sessionID string, usr internal.User) error {
+	return svc.update(ctx, sessionID, func(s *state, _ *sqlc.Queries) error {
+		if err := s.checkFacilitator(sessionID, usr); err != nil {
+			return err
+		}
+
+		if err := svc.stopCountdown(sessionID, s); err != nil {
+			return err
+		}
+
+		if err := svc.clearTimer(sessionID, s); err != nil {
+			return err
+		}
+
+		s.reset()
+
+		if err := svc.ntf.notifyTicket(sessionID, s, allActiveUsers); err != nil {
+			return err
+		}
+
+		if err := svc.ntf.notifyTabs(sessionID, s, allActiveUsers); err != nil {
+			return err
+		}
+
+		return svc.ntf.notifyResults(sessionID, s)
+	})
+}
+
+func (svc *Service) SetFacilitator(ctx context.Context, sessionID, userID string, usr internal.User) error {
+	return svc.update(ctx, sessionID, func(s *state, queries *sqlc.Queries) error {
+		if err := s.checkFacilitator(sessionID, usr); err != nil {
+			return err
+		}
+
+		var target internal.User
+
+		for _, res := range s.Results {
+			if res.User.ID == userID && !res.inactive {
+				target = res.User
+
+				break
+			}
+		}
+
+		if target.ID == "" {
+			return fmt.Errorf("%w: user %s in session %s", internal.ErrNotFound, userID, sessionID)
+		}
+
+		if err := svc.setFacilitator(ctx, queries, sessionID, s, target); err != nil {
+			return err
+		}
+
+		if err := svc.ntf.notifyControls(sessionID, s, allActiveUsers); err != nil {
+			return err
+		}
+
+		if err := svc.ntf.notifyTabs(sessionID, s, allActiveUsers); err != nil {
+			return err
+		}
+
+		return svc.ntf.notifyResults(sessionID, s)
+	})
+}
+
+func (svc *Service) SetAutoReveal(
+	ctx context.Context,
+	sessionID string,
+	autoReveal bool,
+	delay time.Duration,
+	usr internal.User,
+) error {
+	return svc.update(ctx, sessionID, func(s *state, queries *sqlc.Queries) error {
+		if err := s.checkFacilitator(sessionID, usr); err != nil {
+			return err
+		}
+
+		if err := queries.UpdateSessionAutoReveal(ctx, sqlc.UpdateSessionAutoRevealParams{
+			AutoReveal:      autoReveal,
+			AutoRevealDelay: int32(delay.Seconds()),
+			ID:              sessionID,
+		}); err != nil {
+			return err
+		}
+
+		s.AutoReveal = autoReveal
+		s.AutoRevealDelay = delay
+
+		if err := svc.stopCountdown(sessionID, s); err != nil {
+			return err
+		}
+
+		if err := svc.ntf.notifyControls(sessionID, s, allActiveUsers); err != nil {
+			return err
+		}
+
+		return svc.autoReveal(sessionID, s)
+	})
+}
+
+// Revote saves the votes of the current round of the ticket, then starts a new round.
+func (svc *Service) Revote(ctx context.Context, sessionID string, usr internal.User) error {
+	return svc.update(ctx, sessionID, func(s *state, queries *sqlc.Queries) error {
+		if err := s.checkFacilitator(sessionID, usr); err != nil {
+			return err
+		}
+
+		if s.Ticket.Summary == "" {
+			return fmt.Errorf("%w: ticket summary is required to vote again", internal.ErrInvalidInput)
+		}
+
+		s.Ticket.SizingValue = s.chosenValue(usr)
+
+		if s.Ticket.SizingValue == "" {
+			return fmt.Errorf("%w: votes must be revealed, or a card chosen, to vote again", internal.ErrInvalidInput)
+		}
+
+		if err := svc.saveTicket(ctx, queries, sessionID, s); err != nil {
+			return err
+		}
+
+		if err := svc.stopCountdown(sessionID, s); err != nil {
+			return err
+		}
+
+		s.newRound()
+
+		if err := svc.ntf.notifyTabs(sessionID, s, allActiveUsers); err != nil {
+			return err
+		}
+
+		if err := svc.ntf.notifyResults(sessionID, s); err != nil {
+			return err
+		}
+
+		return svc.refreshHistory(ctx, queries, sessionID, s)
+	})
+}
+
+// ReloadDecks refreshes the decks of every live session of the given team,
+// falling back to the default deck when the current one is not available anymore.
+func (svc *Service) ReloadDecks(ctx context.Context, team string) error {
 	svc.mu.RLock()
 	defer svc.mu.RUnlock()
 
-	s, found := svc.stateBySessionID[sessionID]
-	if !found {
-		return fmt.Errorf("%w: session %s", internal.ErrNotFound, sessionID)
-	}
+	for sessionID, s := range svc.stateBySessionID {
+		if s.Team != team {
+			continue
+		}
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
+		if err := svc.reloadDecks(ctx, sessionID, s); err != nil {
+			return err
+		}
+	}
 
-	for _, res := range s.Results {
-		if res.User.Equals(usr) {
-			s.Ticket.SizingValue = res.Sizing
+	return nil
+}
 
-			break
+func (svc *Service) init(ctx context.Context, sessionID string) (*state, error) {
+	session, err := svc.repo.Session(ctx, sessionID)
+	if err != nil {
+		if db.IsErrNoRows(err) {
+			return nil, fmt.Errorf("%w: session %s", internal.ErrNotFound, sessionID)
 		}
+
+		return nil, err
 	}
 
-	if !s.Ticket.valid() {
-		return nil
+	decks, err := svc.decks.List(ctx, session.Team)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := svc.saveTicket(ctx, sessionID, s); err != nil {
+	if len(decks) == 0 {
+		return nil, fmt.Errorf("%w: no deck for team %s", internal.ErrNotFound, session.Team)
+	}
+
+	res := &state{
+		closed:          session.Status != db.SessionOpen,
+		epoch:           ulid.Make().String(),
+		facilitatorID:   session.FacilitatorID,
+		AutoReveal:      session.AutoReveal,
+		AutoRevealDelay: time.Duration(session.AutoRevealDelay) * time.Second,
+		Decks:           decks,
+		Results:         make([]result, 0, 1),
+		Team:            session.Team,
+		Ticket:          &ticket{DeckID: decks[0].ID, Round: 1},
+	}
+
+	svc.bus.Subscribe(sessionID)
+
+	if err = svc.restore(ctx, sessionID, res); err != nil {
+		return nil, err
+	}
+
+	res.History, err = svc.history(ctx, svc.repo.Queries, session.Team, res.Deck())
+	if err != nil {
+		return nil, err
+	}
+
+	if err = svc.loadBacklog(ctx, svc.repo.Queries, sessionID, res); err != nil {
+		return nil, err
+	}
+
+	svc.stateBySessionID[sessionID] = res
+
+	return res, nil
+}
+
+func (svc *Service) reloadDecks(ctx context.Context, sessionID string, s *state) error {
+	decks, err := svc.decks.List(ctx, s.Team)
+	if err != nil {
 		return err
 	}
 
-	history, err := svc.history(ctx, s.Team, s.Ticket.SizingType)
+	if len(decks) == 0 {
+		return fmt.Errorf("%w: no deck for team %s", internal.ErrNotFound, s.Team)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return svc.apply(ctx, sessionID, s, func(queries *sqlc.Queries) error {
+		s.Decks = decks
+
+		if s.Deck().ID == 0 {
+			if err := svc.stopCountdown(sessionID, s); err != nil {
+				return err
+			}
+
+			s.switchDeck(decks[0].ID)
+
+			if err := svc.ntf.notifyResults(sessionID, s); err != nil {
+				return err
+			}
+		}
+
+		if err := svc.ntf.notifyTabs(sessionID, s, allActiveUsers); err != nil {
+			return err
+		}
+
+		return svc.refreshHistory(ctx, queries, sessionID, s)
+	})
+}
+
+// refreshHistory reloads the history of the current deck and broadcasts it.
+// It must be called with the session state locked.
+func (svc *Service) refreshHistory(ctx context.Context, queries *sqlc.Queries, sessionID string, s *state) error {
+	history, err := svc.history(ctx, queries, s.Team, s.Deck())
 	if err != nil {
 		return err
 	}
@@ -187,151 +670,262 @@ func (svc *Service) AddTicketToHistory(ctx context.Context, sessionID string, us
 	return svc.ntf.notifyHistory(sessionID, s, allActiveUsers)
 }
 
-func (svc *Service) ToggleSizings(sessionID string) error {
-	svc.mu.RLock()
-	defer svc.mu.RUnlock()
-
-	s, found := svc.stateBySessionID[sessionID]
-	if !found {
-		return fmt.Errorf("%w: session %s", internal.ErrNotFound, sessionID)
+// update runs fn with the locked state of the session, see apply.
+func (svc *Service) update(
+	ctx context.Context,
+	sessionID string,
+	fn func(s *state, queries *sqlc.Queries) error,
+) error {
+	s, err := svc.lockedState(ctx, sessionID)
+	if err != nil {
+		return err
 	}
 
-	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.Show = !s.Show
+	return svc.apply(ctx, sessionID, s, func(queries *sqlc.Queries) error {
+		if err := s.checkOpen(sessionID); err != nil {
+			return err
+		}
 
-	return svc.ntf.notifyResults(sessionID, s)
+		return fn(s, queries)
+	})
 }
 
-func (svc *Service) SwitchSizingType(ctx context.Context, sessionID, sizingType string) error {
+// state returns the state of the session, initializing it if no user joined the session on this replica,
+// like for API clients.
+func (svc *Service) state(ctx context.Context, sessionID string) (*state, error) {
 	svc.mu.RLock()
-	defer svc.mu.RUnlock()
-
 	s, found := svc.stateBySessionID[sessionID]
-	if !found {
-		return fmt.Errorf("%w: session %s", internal.ErrNotFound, sessionID)
+	svc.mu.RUnlock()
+
+	if found {
+		return s, nil
 	}
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
 
-	s.Ticket.SizingType = sizingType
-	s.Ticket.SizingValue = ""
+	if s, found = svc.stateBySessionID[sessionID]; found {
+		return s, nil
+	}
+
+	return svc.init(ctx, sessionID)
+}
 
-	for i := range s.Results {
-		s.Results[i].Sizing = ""
+// lockedState returns the state of the session with its lock held,
+// looking it up again if it was removed as empty before it could be locked.
+func (svc *Service) lockedState(ctx context.Context, sessionID string) (*state, error) {
+	for {
+		s, err := svc.state(ctx, sessionID)
+		if err != nil {
+			return nil, err
+		}
+
+		s.mu.Lock()
+
+		if !s.dropped {
+			return s, nil
+		}
+
+		s.mu.Unlock()
 	}
+}
+
+// apply runs fn once the state has caught up with the last snapshot saved by any replica,
+// then saves the updated state, notifies the other replicas and publishes the events queued by fn.
+// The queries given to fn are committed along with the state, so fn must not use the repository meanwhile.
+// Events for users of this replica are only rendered and sent once the snapshot is saved and unlocked.
+// It must be called with the session state locked.
+func (svc *Service) apply(ctx context.Context, sessionID string, s *state, fn func(queries *sqlc.Queries) error) error {
+	s.pending = nil
+
+	defer svc.flush(sessionID, s)
+
+	err := svc.store.Update(ctx, sessionID, svc.replicaID, func(queries *sqlc.Queries, data []byte) ([]byte, error) {
+		if data != nil {
+			if err := svc.merge(sessionID, s, data); err != nil {
+				return nil, err
+			}
+		}
+
+		shown := s.Show
+
+		if err := fn(queries); err != nil {
+			return nil, err
+		}
+
+		if s.Show && !shown {
+			svc.publish(sessionID, s, pubsub.VotesRevealed)
+		}
 
-	if err := svc.ntf.notifyTabs(sessionID, s, allActiveUsers, false); err != nil {
+		return json.Marshal(s.snapshot())
+	})
+
+	events := s.pending
+	s.pending = nil
+
+	if err != nil {
 		return err
 	}
 
-	if err := svc.ntf.notifyResults(sessionID, s); err != nil {
-		return err
+	for _, evt := range events {
+		svc.pubsub.Publish(ctx, evt)
 	}
 
-	history, err := svc.history(ctx, s.Team, s.Ticket.SizingType)
-	if err != nil {
+	return nil
+}
+
+// merge applies a snapshot saved by any replica to the state.
+// It must be called with the session state locked.
+func (svc *Service) merge(sessionID string, s *state, data []byte) error {
+	var snp snapshot
+
+	if err := json.Unmarshal(data, &snp); err != nil {
 		return err
 	}
 
-	s.History = history
+	// users removed through another replica are told so before being disconnected
+	for _, res := range s.Results {
+		if _, found := snp.Removed[res.User.ID]; found && res.local() {
+			if err := svc.ntf.notifyRemoved(sessionID, s, res.User); err != nil {
+				return err
+			}
+		}
+	}
 
-	return svc.ntf.notifyHistory(sessionID, s, allActiveUsers)
-}
+	if snp.Closed && !s.closed {
+		// closed through another replica
+		if err := svc.close(sessionID, s); err != nil {
+			return err
+		}
+	}
 
-func (svc *Service) SetSizingValue(sessionID, sizingValue string, usr internal.User) error {
-	svc.mu.RLock()
-	defer svc.mu.RUnlock()
+	s.merge(snp, svc.replicaID)
 
-	s, found := svc.stateBySessionID[sessionID]
-	if !found {
-		return fmt.Errorf("%w: session %s", internal.ErrNotFound, sessionID)
+	if s.closed {
+		return nil
 	}
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	if err := svc.syncCountdown(sessionID, s, snp.RevealAt); err != nil {
+		return err
+	}
 
-	for i, res := range s.Results {
-		if res.User.Equals(usr) {
-			s.Results[i].Sizing = sizingValue
+	return svc.syncTimer(sessionID, s, snp.Timer)
+}
 
-			break
+// restore applies the last saved snapshot, if any, to a state being initialized:
+// users connected to replicas which are not running anymore are considered inactive until they join again.
+func (svc *Service) restore(ctx context.Context, sessionID string, s *state) error {
+	data, err := svc.store.Load(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, internal.ErrNotFound) {
+			return nil
 		}
+
+		return err
 	}
 
-	if err := svc.ntf.notifyTabs(sessionID, s, includeUser(usr), true); err != nil {
+	replicas, err := svc.bus.Replicas(ctx)
+	if err != nil {
 		return err
 	}
 
-	if err := svc.ntf.notifyResults(sessionID, s); err != nil {
+	slog.Info("Restoring session state", slog.String(internal.LogKeySession, sessionID))
+
+	if err = svc.merge(sessionID, s, data); err != nil {
 		return err
 	}
 
+	s.deactivateReplicas(append(replicas, svc.replicaID))
+
 	return nil
 }
 
-func (svc *Service) ResetSession(sessionID string) error {
+// refresh renders the state saved by another replica to the users connected to this replica.
+func (svc *Service) refresh(sessionID, replicaID string) {
+	if replicaID == svc.replicaID {
+		return
+	}
+
+	if err := svc.doRefresh(context.Background(), sessionID); err != nil {
+		internal.LogError("Failed to refresh live state of session "+sessionID, err)
+	}
+}
+
+func (svc *Service) doRefresh(ctx context.Context, sessionID string) error {
 	svc.mu.RLock()
 	defer svc.mu.RUnlock()
 
 	s, found := svc.stateBySessionID[sessionID]
 	if !found {
-		return fmt.Errorf("%w: session %s", internal.ErrNotFound, sessionID)
+		return nil
+	}
+
+	decks, err := svc.decks.List(ctx, s.Team)
+	if err != nil {
+		return err
+	}
+
+	data, err := svc.store.Load(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, internal.ErrNotFound) {
+			return nil
+		}
+
+		return err
 	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	defer svc.flush(sessionID, s)
 
-	s.reset()
+	if len(decks) > 0 {
+		s.Decks = decks
+	}
+
+	tck := *s.Ticket
 
-	if err := svc.ntf.notifyTicket(sessionID, s, allActiveUsers); err != nil {
+	if err = svc.merge(sessionID, s, data); err != nil {
 		return err
 	}
 
-	if err := svc.ntf.notifyTabs(sessionID, s, allActiveUsers, false); err != nil {
+	if err = svc.ntf.notifyControls(sessionID, s, allActiveUsers); err != nil {
 		return err
 	}
 
-	if err := svc.ntf.notifyResults(sessionID, s); err != nil {
+	if err = svc.ntf.notifyTabs(sessionID, s, allActiveUsers); err != nil {
 		return err
 	}
 
-	return nil
-}
-
-func (svc *Service) init(ctx context.Context, sessionID string) (*state, error) {
-	session, err := svc.repo.Session(ctx, sessionID)
-	if err != nil {
-		if db.IsErrNoRows(err) {
-			return nil, fmt.Errorf("%w: session %s", internal.ErrNotFound, sessionID)
-		}
+	if err = svc.ntf.notifyResults(sessionID, s); err != nil {
+		return err
+	}
 
-		return nil, err
+	if err = svc.loadBacklog(ctx, svc.repo.Queries, sessionID, s); err != nil {
+		return err
 	}
 
-	history, err := svc.history(ctx, session.Team, defaultSizingType)
-	if err != nil {
-		return nil, err
+	if err = svc.ntf.notifyBacklog(sessionID, s, allActiveUsers); err != nil {
+		return err
 	}
 
-	res := &state{
-		History: history,
-		Results: make([]result, 0, 1),
-		Team:    session.Team,
-		Ticket:  &ticket{SizingType: defaultSizingType},
+	if *s.Ticket == tck {
+		return nil
 	}
 
-	svc.stateBySessionID[sessionID] = res
+	// only when changed, not to overwrite a summary being typed
+	if err = svc.ntf.notifyTicket(sessionID, s, allActiveUsers); err != nil {
+		return err
+	}
 
-	return res, nil
+	return svc.refreshHistory(ctx, svc.repo.Queries, sessionID, s)
 }
 
-func (svc *Service) history(ctx context.Context, team, sizingType string) ([]ticket, error) {
-	tickets, err := svc.repo.History(ctx, sqlc.HistoryParams{
-		Team:       team,
-		SizingType: sizingType,
+func (svc *Service) history(ctx context.Context, queries *sqlc.Queries, team string, dck deck.Deck) ([]ticket, error) {
+	tickets, err := queries.History(ctx, sqlc.HistoryParams{
+		Team:   team,
+		DeckID: dck.ID,
 	})
 	if err != nil {
 		return nil, err
@@ -347,45 +941,66 @@ func (svc *Service) history(ctx context.Context, team, sizingType string) ([]tic
 				ID:          tck.ID,
 				Summary:     tck.Summary,
 				URL:         tck.Url,
-				SizingType:  tck.SizingType,
+				DeckID:      tck.DeckID,
 				SizingValue: tck.SizingValue,
 			})
 		}
 	}
 
-	switch sizingType {
-	case SizingTypeStoryPoints:
-		return sortTickets(ticketsByValue, SizingValueStoryPoints), nil
-	case SizingTypeTShirt:
-		return sortTickets(ticketsByValue, SizingValueTShirt), nil
+	return sortTickets(ticketsByValue, dck.Values()), nil
+}
+
+func (svc *Service) setFacilitator(
+	ctx context.Context,
+	queries *sqlc.Queries,
+	sessionID string,
+	s *state,
+	usr internal.User,
+) error {
+	slog.Info("Setting session facilitator",
+		slog.String(internal.LogKeySession, sessionID),
+		slog.String(internal.LogKeyUser, usr.Name),
+	)
+
+	if err := queries.UpdateSessionFacilitator(ctx, sqlc.UpdateSessionFacilitatorParams{
+		FacilitatorID: usr.ID,
+		ID:            sessionID,
+	}); err != nil {
+		return err
 	}
 
-	return []ticket{}, nil
+	s.facilitatorID = usr.ID
+
+	return nil
 }
 
-func (svc *Service) saveTicket(ctx context.Context, sessionID string, s *state) error {
-	if s.Ticket.ID > 0 {
+// saveTicket creates or updates the current ticket, along with the votes of its current round.
+// It must be called within the update of the session state, whose transaction the queries belong to.
+func (svc *Service) saveTicket(ctx context.Context, queries *sqlc.Queries, sessionID string, s *state) error {
+	ticketID := s.Ticket.ID
+
+	if ticketID > 0 {
 		slog.Info("Updating ticket...",
 			slog.String(internal.LogKeySession, sessionID),
-			slog.Int64("ticketID", s.Ticket.ID),
+			slog.Int64("ticketID", ticketID),
 		)
 
-		if err := svc.repo.UpdateTicket(ctx, sqlc.UpdateTicketParams{
+		if err := queries.UpdateTicket(ctx, sqlc.UpdateTicketParams{
 			Summary:     s.Ticket.Summary,
 			Url:         s.Ticket.URL,
-			SizingType:  s.Ticket.SizingType,
+			DeckID:      s.Ticket.DeckID,
 			SizingValue: s.Ticket.SizingValue,
-			ID:          s.Ticket.ID,
+			ID:          ticketID,
 		}); err != nil {
 			return err
 		}
 	} else {
 		slog.Info("Creating ticket...", slog.String(internal.LogKeySession, sessionID))
 
-		tck, err := svc.repo.CreateTicket(ctx, sqlc.CreateTicketParams{
+		tck, err := queries.CreateTicket(ctx, sqlc.CreateTicketParams{
 			Summary:     s.Ticket.Summary,
 			Url:         s.Ticket.URL,
-			SizingType:  s.Ticket.SizingType,
+			DeckID:      s.Ticket.DeckID,
 			SizingValue: s.Ticket.SizingValue,
 			SessionID:   sessionID,
 		})
@@ -393,16 +1008,65 @@ func (svc *Service) saveTicket(ctx context.Context, sessionID string, s *state)
 			return err
 		}
 
-		s.Ticket.ID = tck.ID
+		ticketID = tck.ID
+	}
+
+	if err := saveVotes(ctx, queries, ticketID, s); err != nil {
+		return err
+	}
+
+	s.Ticket.ID = ticketID
+
+	return nil
+}
+
+// saveVotes replaces the votes of the current round of the ticket.
+func saveVotes(ctx context.Context, queries *sqlc.Queries, ticketID int64, s *state) error {
+	round := int32(s.Ticket.Round) //nolint:gosec
+
+	if err := queries.DeleteVotes(ctx, sqlc.DeleteVotesParams{
+		TicketID: ticketID,
+		Round:    round,
+	}); err != nil {
+		return err
+	}
+
+	for _, res := range s.Results {
+		if res.Sizing == "" {
+			continue
+		}
+
+		if err := queries.CreateVote(ctx, sqlc.CreateVoteParams{
+			TicketID:  ticketID,
+			Round:     round,
+			UserID:    res.User.ID,
+			UserName:  res.User.Name,
+			Value:     res.Sizing,
+			CreatedAt: pgtype.Timestamp{Time: res.votedAt, Valid: true},
+		}); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+func (svc *Service) startListening() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		<-svc.done
+		cancel()
+	}()
+
+	svc.bus.Run(ctx, svc.replicaID, svc.refresh)
+}
+
 func (svc *Service) startRemoveEmptySessions(d time.Duration) {
 	slog.Info("Starting empty sessions remover", slog.String("tick", d.String()))
 
-	ticker := time.NewTicker(d)
+	ticker := svc.clk.NewTicker(d)
 	defer ticker.Stop()
 
 	for {
@@ -411,7 +1075,7 @@ func (svc *Service) startRemoveEmptySessions(d time.Duration) {
 			slog.Info("Server is shutting down, stopping empty sessions remover...")
 
 			return
-		case <-ticker.C:
+		case <-ticker.C():
 			svc.removeEmptySessions()
 		}
 	}
@@ -424,18 +1088,30 @@ func (svc *Service) removeEmptySessions() {
 	slog.Info("Removing empty sessions...")
 
 	for sessionID, s := range svc.stateBySessionID {
+		// a session in use is not empty, and waiting for it would block every other session
+		if !s.mu.TryLock() {
+			continue
+		}
+
 		if s.empty() {
 			slog.Info("Removing session...", slog.String(internal.LogKeySession, sessionID))
 
+			s.dropped = true
+
 			delete(svc.stateBySessionID, sessionID)
+
+			svc.bus.Unsubscribe(sessionID)
 		}
+
+		s.mu.Unlock()
 	}
 }
 
-func (svc *Service) startDeactivateUsers(sessionID string, s *state) {
+// startDeactivateUsers deactivates the users who left once their inactivity delay is over,
+// until none is left to deactivate.
+func (svc *Service) startDeactivateUsers(sessionID string, s *state, ticker internal.Ticker) {
 	slog.Info("Starting users deactivation", slog.String("tick", svc.maxInactiveTime.String()))
 
-	ticker := time.NewTicker(svc.maxInactiveTime)
 	defer ticker.Stop()
 
 	for {
@@ -444,36 +1120,59 @@ func (svc *Service) startDeactivateUsers(sessionID string, s *state) {
 			slog.Info("Server is shutting down, stopping cleaner...")
 
 			return
-		case <-ticker.C:
-			svc.deactivateUsers(sessionID, s)
-
-			return
+		case <-ticker.C():
+			if !svc.deactivateUsers(sessionID, s) {
+				return
+			}
 		}
 	}
 }
 
-func (svc *Service) deactivateUsers(sessionID string, s *state) {
+// deactivateUsers returns whether users who left are still to be deactivated.
+func (svc *Service) deactivateUsers(sessionID string, s *state) bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	notify := false
 	now := svc.clk.Now()
+	leaving := func(res result) bool {
+		return !res.inactive && !res.maxInactiveTime.IsZero()
+	}
+	expired := func(res result) bool {
+		return leaving(res) && !res.maxInactiveTime.After(now)
+	}
 
-	for i, res := range s.Results {
-		if !res.maxInactiveTime.IsZero() && res.maxInactiveTime.Before(now) {
-			slog.Info("Marking user as inactive",
-				slog.String(internal.LogKeySession, sessionID),
-				slog.String(internal.LogKeyUser, res.User.Name),
-			)
+	if !slices.ContainsFunc(s.Results, expired) {
+		s.deactivating = slices.ContainsFunc(s.Results, leaving)
 
-			s.Results[i].inactive = true
-			notify = true
-		}
+		return s.deactivating
 	}
 
-	if notify {
-		_ = svc.ntf.notifyResults(sessionID, s)
+	err := svc.apply(context.Background(), sessionID, s, func(_ *sqlc.Queries) error {
+		for i, res := range s.Results {
+			if expired(res) {
+				slog.Info("Marking user as inactive",
+					slog.String(internal.LogKeySession, sessionID),
+					slog.String(internal.LogKeyUser, res.User.Name),
+				)
+
+				s.Results[i].inactive = true
+				s.Results[i].maxInactiveTime = time.Time{}
+			}
+		}
+
+		if err := svc.ntf.notifyResults(sessionID, s); err != nil {
+			return err
+		}
+
+		return svc.autoReveal(sessionID, s)
+	})
+	if err != nil {
+		internal.LogError("Failed to deactivate users of session "+sessionID, err)
 	}
+
+	s.deactivating = slices.ContainsFunc(s.Results, leaving)
+
+	return s.deactivating
 }
 
 func includeUser(usr internal.User) notifyUserFunc {
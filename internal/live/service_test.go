@@ -0,0 +1,495 @@
+package live
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MartyHub/size-it/internal"
+	"github.com/MartyHub/size-it/internal/db/sqlc"
+	"github.com/MartyHub/size-it/internal/deck"
+	"github.com/MartyHub/size-it/internal/metrics"
+	"github.com/MartyHub/size-it/internal/pubsub"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	testBufferSize = 8
+	testSessionID  = "S"
+)
+
+var (
+	alice = internal.User{ID: "A", Name: "Alice", Team: "T"} //nolint:gochecknoglobals
+	bob   = internal.User{ID: "B", Name: "Bob", Team: "T"}   //nolint:gochecknoglobals
+)
+
+type (
+	// memoryStore keeps snapshots in memory, tracking whether one is being updated.
+	memoryStore struct {
+		mu       sync.Mutex
+		data     map[string][]byte
+		updating bool
+	}
+
+	// localBus runs a single replica.
+	localBus struct{}
+
+	// testRenderer writes the name of the template, followed by the remaining time of timers and countdowns.
+	testRenderer struct {
+		store *memoryStore
+		t     *testing.T
+	}
+)
+
+func (store *memoryStore) Load(_ context.Context, sessionID string) ([]byte, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	data, found := store.data[sessionID]
+	if !found {
+		return nil, fmt.Errorf("%w: live state of session %s", internal.ErrNotFound, sessionID)
+	}
+
+	return data, nil
+}
+
+func (store *memoryStore) Update(
+	_ context.Context,
+	sessionID, _ string,
+	fn func(queries *sqlc.Queries, data []byte) ([]byte, error),
+) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	store.updating = true
+	defer func() { store.updating = false }()
+
+	data, err := fn(nil, store.data[sessionID])
+	if err != nil {
+		return err
+	}
+
+	store.data[sessionID] = data
+
+	return nil
+}
+
+func (bus localBus) Run(ctx context.Context, _ string, _ func(sessionID, replicaID string)) {
+	<-ctx.Done()
+}
+
+func (bus localBus) Subscribe(string) {}
+
+func (bus localBus) Unsubscribe(string) {}
+
+func (bus localBus) Replicas(context.Context) ([]string, error) {
+	return nil, nil
+}
+
+func (rdr testRenderer) Render(w io.Writer, name string, data any, _ echo.Context) error {
+	if rdr.store.updating {
+		rdr.t.Errorf("%s rendered while saving the snapshot", name)
+	}
+
+	s, _ := data.(map[string]any)["state"].(*state)
+
+	switch {
+	case name == "components/timer.gohtml" && s.Timer != nil:
+		_, err := fmt.Fprintf(w, "%s %s", name, s.Timer.Text())
+
+		return err
+	case name == "components/countdown.gohtml" && s.Countdown != nil:
+		_, err := fmt.Fprintf(w, "%s %d", name, s.Countdown.Remaining)
+
+		return err
+	}
+
+	_, err := io.WriteString(w, name)
+
+	return err
+}
+
+// newTestService returns a service holding an open session facilitated by alice, without any user yet.
+func newTestService(t *testing.T, clk internal.Clock) *Service {
+	t.Helper()
+
+	done := make(chan struct{})
+	store := &memoryStore{data: make(map[string][]byte)}
+
+	t.Cleanup(func() { close(done) })
+
+	svc := NewService(
+		done,
+		internal.Config{EmptySessionsTick: time.Hour, MaxInactiveTime: time.Minute, RejoinDelay: time.Minute},
+		clk,
+		testRenderer{store: store, t: t},
+		nil,
+		metrics.NewRegistry(),
+		pubsub.NewBus(),
+		store,
+		localBus{},
+	)
+
+	weight := 1.0
+
+	svc.stateBySessionID[testSessionID] = &state{
+		epoch:         "E",
+		facilitatorID: alice.ID,
+		Decks: []deck.Deck{
+			{ID: 1, Name: "Story Points", Cards: []deck.Card{{Value: "1", Weight: &weight}, {Value: "﹖"}}},
+		},
+		Results: make([]result, 0, 1),
+		Team:    "T",
+		Ticket:  &ticket{DeckID: 1, Round: 1},
+	}
+
+	return svc
+}
+
+// join connects usr to the test session, then drains the events sent on join.
+func join(t *testing.T, svc *Service, usr internal.User, size int) chan Event {
+	t.Helper()
+
+	events := make(chan Event, size)
+
+	if err := svc.Join(context.Background(), testSessionID, usr, events, ""); err != nil {
+		t.Fatalf("join %s: %v", usr.Name, err)
+	}
+
+	drain(events)
+
+	return events
+}
+
+func drain(events chan Event) {
+	for {
+		select {
+		case _, ok := <-events:
+			if !ok {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+// next waits for the next event of the given kind, skipping others.
+func next(t *testing.T, events chan Event, kind string) Event {
+	t.Helper()
+
+	timeout := time.After(time.Second)
+
+	for {
+		select {
+		case evt, ok := <-events:
+			if !ok {
+				t.Fatalf("events closed while waiting for %s", kind)
+			}
+
+			if evt.Kind == kind {
+				return evt
+			}
+		case <-timeout:
+			t.Fatalf("no %s event", kind)
+		}
+	}
+}
+
+func TestService_sendsAfterSaving(t *testing.T) {
+	svc := newTestService(t, internal.NewFixedClock(time.Now()))
+	events := join(t, svc, alice, testBufferSize)
+
+	if err := svc.Reveal(context.Background(), testSessionID, true, alice); err != nil {
+		t.Fatal(err)
+	}
+
+	if evt := next(t, events, "results"); !strings.HasSuffix(string(evt.Data), "results.gohtml") {
+		t.Errorf("unexpected results: %s", evt.Data)
+	}
+}
+
+func TestService_disconnectsSlowUsers(t *testing.T) {
+	svc := newTestService(t, internal.NewFixedClock(time.Now()))
+	ctx := context.Background()
+
+	join(t, svc, alice, testBufferSize)
+
+	slow := join(t, svc, bob, testBufferSize)
+
+	// bob stops reading events
+	for range 2 * testBufferSize {
+		if err := svc.Reveal(ctx, testSessionID, true, alice); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	count := 0
+
+	for range slow {
+		count++
+	}
+
+	if count > testBufferSize {
+		t.Errorf("got %d events, beyond the buffer of %d", count, testBufferSize)
+	}
+
+	s := svc.stateBySessionID[testSessionID]
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, res := range s.Results {
+		if res.User.Equals(bob) && (res.local() || res.maxInactiveTime.IsZero()) {
+			t.Errorf("slow user still connected: %+v", res)
+		}
+	}
+}
+
+func TestService_Join_busySession(t *testing.T) {
+	svc := newTestService(t, internal.NewFixedClock(time.Now()))
+	busy := &state{
+		epoch:         "B",
+		facilitatorID: bob.ID,
+		Decks:         svc.stateBySessionID[testSessionID].Decks,
+		Results:       make([]result, 0, 1),
+		Team:          "T",
+		Ticket:        &ticket{DeckID: 1, Round: 1},
+	}
+
+	svc.stateBySessionID["B"] = busy
+
+	busy.mu.Lock()
+
+	joined := make(chan error, 1)
+
+	go func() {
+		joined <- svc.Join(context.Background(), "B", bob, make(chan Event, testBufferSize), "")
+	}()
+
+	// joining a session does not wait for another one, even once bob waits for the busy session
+	time.Sleep(100 * time.Millisecond)
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		join(t, svc, alice, testBufferSize)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("join blocked by another session")
+	}
+
+	busy.mu.Unlock()
+
+	if err := <-joined; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestService_Attend(t *testing.T) {
+	svc := newTestService(t, internal.NewFixedClock(time.Now()))
+	ctx := context.Background()
+	events := join(t, svc, alice, testBufferSize)
+
+	if err := svc.SetSizingValue(ctx, testSessionID, 1, "1", bob); err == nil {
+		t.Error("voted before attending")
+	}
+
+	if err := svc.Attend(ctx, testSessionID, bob); err != nil {
+		t.Fatal(err)
+	}
+
+	next(t, events, "results")
+
+	if err := svc.SetSizingValue(ctx, testSessionID, 1, "1", bob); err != nil {
+		t.Fatal(err)
+	}
+
+	// attending does not disconnect users joined with events
+	if err := svc.Attend(ctx, testSessionID, alice); err != nil {
+		t.Fatal(err)
+	}
+
+	s := svc.stateBySessionID[testSessionID]
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, res := range s.Results {
+		switch {
+		case res.User.Equals(alice) && (!res.local() || !res.maxInactiveTime.IsZero()):
+			t.Errorf("joined user changed: %+v", res)
+		case res.User.Equals(bob) && (res.local() || res.inactive || res.maxInactiveTime.IsZero() || res.Sizing != "1"):
+			t.Errorf("unexpected attending user: %+v", res)
+		}
+	}
+}
+
+func TestService_Attend_deactivates(t *testing.T) {
+	clk := internal.NewFixedClock(time.Now())
+	svc := newTestService(t, clk)
+	ctx := context.Background()
+	events := join(t, svc, alice, testBufferSize)
+	s := svc.stateBySessionID[testSessionID]
+
+	attend := func() {
+		t.Helper()
+
+		if err := svc.Attend(ctx, testSessionID, bob); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	attend()
+	next(t, events, "results")
+	attend()
+
+	// attending again postpones the deactivation
+	clk.Advance(30 * time.Second)
+	attend()
+	clk.Advance(30 * time.Second)
+	none(t, events, "results")
+
+	clk.Advance(time.Minute)
+	next(t, events, "results")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.deactivating {
+		t.Error("still deactivating users")
+	}
+
+	for _, res := range s.Results {
+		if res.User.Equals(bob) && !res.inactive {
+			t.Errorf("attending user not deactivated: %+v", res)
+		}
+	}
+}
+
+func TestService_SetFacilitator(t *testing.T) {
+	svc := newTestService(t, internal.NewFixedClock(time.Now()))
+	ctx := context.Background()
+	s := svc.stateBySessionID[testSessionID]
+
+	join(t, svc, alice, testBufferSize)
+	join(t, svc, bob, testBufferSize)
+	s.mu.Lock()
+	s.Results[0].inactive = true
+	s.mu.Unlock()
+
+	// the facilitator role can only be handed over, even once the facilitator is inactive
+	if err := svc.SetFacilitator(ctx, testSessionID, bob.ID, bob); !errors.Is(err, internal.ErrUnauthorized) {
+		t.Errorf("SetFacilitator by bob = %v, want unauthorized", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.IsFacilitator(alice) {
+		t.Errorf("facilitator = %s, want alice", s.facilitatorID)
+	}
+}
+
+// last drains events, returning the last one.
+func last(t *testing.T, events chan Event) Event {
+	t.Helper()
+
+	var res Event
+
+	for {
+		select {
+		case evt := <-events:
+			res = evt
+		default:
+			if res.ID == "" {
+				t.Fatal("no event")
+			}
+
+			return res
+		}
+	}
+}
+
+// kinds drains events, returning the number of events of each kind.
+func kinds(events chan Event) map[string]int {
+	res := make(map[string]int)
+
+	for {
+		select {
+		case evt := <-events:
+			res[evt.Kind]++
+		default:
+			return res
+		}
+	}
+}
+
+func TestService_Join_resumes(t *testing.T) {
+	const reveals = 10
+
+	tests := []struct {
+		name    string
+		users   int
+		reveals int
+		resumed bool
+	}{
+		{name: "many users", users: 16, reveals: reveals, resumed: true},
+		{name: "too many events", users: 1, reveals: maxSentEvents + 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestService(t, internal.NewFixedClock(time.Now()))
+			ctx := context.Background()
+			size := 2 * maxSentEvents
+
+			join(t, svc, alice, size)
+
+			for i := range tt.users {
+				join(t, svc, internal.User{ID: fmt.Sprintf("U%d", i), Team: "T"}, size)
+			}
+
+			events := make(chan Event, size)
+
+			if err := svc.Join(ctx, testSessionID, bob, events, ""); err != nil {
+				t.Fatal(err)
+			}
+
+			lastEventID := last(t, events).ID
+
+			for range tt.reveals {
+				if err := svc.Reveal(ctx, testSessionID, true, alice); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// bob reconnects
+			events = make(chan Event, size)
+
+			if err := svc.Join(ctx, testSessionID, bob, events, lastEventID); err != nil {
+				t.Fatal(err)
+			}
+
+			got := kinds(events)
+
+			if resumed := got["ticket"] == 0; resumed != tt.resumed {
+				t.Errorf("resumed = %t, want %t: %v", resumed, tt.resumed, got)
+			}
+
+			if tt.resumed && got["results"] != tt.reveals+1 {
+				t.Errorf("got %d results, want the %d missed ones and the current one", got["results"], tt.reveals)
+			}
+		})
+	}
+}
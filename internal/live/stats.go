@@ -0,0 +1,171 @@
+package live
+
+import (
+	"cmp"
+	"math"
+	"slices"
+
+	"github.com/MartyHub/size-it/internal/deck"
+)
+
+// disagreementSpread is the number of cards between the lowest and highest votes
+// from which the team is considered in high disagreement.
+const disagreementSpread = 3
+
+type stats struct {
+	Count     int      `json:"count"`
+	Average   float64  `json:"average"`
+	Median    float64  `json:"median"`
+	Min       string   `json:"min"`
+	Max       string   `json:"max"`
+	Mode      []string `json:"mode"`
+	Suggested string   `json:"suggested"`
+
+	Consensus    bool `json:"consensus"`
+	Disagreement bool `json:"disagreement"`
+}
+
+// Stats summarizes votes of active users using the weights of the current deck,
+// votes for cards without weight such as "﹖" are ignored.
+func (s *state) Stats() stats {
+	dck := s.Deck()
+	cards := weightedCards(dck)
+
+	var (
+		res   stats
+		votes []deck.Card
+	)
+
+	countByValue := make(map[string]int)
+
+	for _, r := range s.Results {
+		if r.inactive || r.Sizing == "" {
+			continue
+		}
+
+		card, found := dck.Card(r.Sizing)
+		if !found || card.Weight == nil {
+			continue
+		}
+
+		votes = append(votes, card)
+		countByValue[card.Value]++
+	}
+
+	res.Count = len(votes)
+
+	if res.Count == 0 {
+		return res
+	}
+
+	// votes are sorted as cards, not weights, to tell apart cards of the same weight
+	slices.SortStableFunc(votes, compareWeights)
+
+	weights := make([]float64, len(votes))
+
+	for i, card := range votes {
+		weights[i] = *card.Weight
+	}
+
+	res.Average = average(weights)
+	res.Median = median(weights)
+	res.Min = votes[0].Value
+	res.Max = votes[len(votes)-1].Value
+	res.Mode = mode(cards, countByValue)
+	res.Suggested = nearest(cards, res.Median).Value
+
+	// the median vote itself, rather than another card of the same weight
+	if card := votes[len(votes)/2]; *card.Weight == res.Median { //nolint:mnd
+		res.Suggested = card.Value
+	}
+
+	res.Consensus = res.Count > 1 && res.Min == res.Max
+	res.Disagreement = cardPosition(cards, res.Max)-cardPosition(cards, res.Min) >= disagreementSpread
+
+	return res
+}
+
+// weightedCards returns cards having a weight, sorted by weight.
+func weightedCards(dck deck.Deck) []deck.Card {
+	var res []deck.Card
+
+	for _, card := range dck.Cards {
+		if card.Weight != nil {
+			res = append(res, card)
+		}
+	}
+
+	slices.SortStableFunc(res, compareWeights)
+
+	return res
+}
+
+func compareWeights(a, b deck.Card) int {
+	return cmp.Compare(*a.Weight, *b.Weight)
+}
+
+func average(weights []float64) float64 {
+	var sum float64
+
+	for _, weight := range weights {
+		sum += weight
+	}
+
+	return sum / float64(len(weights))
+}
+
+// median expects sorted weights.
+func median(weights []float64) float64 {
+	middle := len(weights) / 2 //nolint:mnd
+
+	if len(weights)%2 == 0 {
+		return (weights[middle-1] + weights[middle]) / 2 //nolint:mnd
+	}
+
+	return weights[middle]
+}
+
+func mode(cards []deck.Card, countByValue map[string]int) []string {
+	var (
+		maxCount int
+		res      []string
+	)
+
+	for _, card := range cards {
+		count := countByValue[card.Value]
+
+		switch {
+		case count == 0 || count < maxCount:
+			continue
+		case count > maxCount:
+			maxCount = count
+			res = res[:0]
+		}
+
+		res = append(res, card.Value)
+	}
+
+	return res
+}
+
+func cardPosition(cards []deck.Card, value string) int {
+	return slices.IndexFunc(cards, func(card deck.Card) bool {
+		return card.Value == value
+	})
+}
+
+// nearest returns the card whose weight is the closest to the given one, the highest card wins ties.
+func nearest(cards []deck.Card, weight float64) deck.Card {
+	var res deck.Card
+
+	minDelta := math.Inf(1)
+
+	for _, card := range cards {
+		if delta := math.Abs(*card.Weight - weight); delta <= minDelta {
+			minDelta = delta
+			res = card
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,115 @@
+package live
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/MartyHub/size-it/internal"
+	"github.com/MartyHub/size-it/internal/deck"
+)
+
+// withVotes makes a user vote for each of the given values, an empty one standing for an inactive user.
+func withVotes(s *state, values ...string) *state {
+	s.Results = make([]result, len(values))
+
+	for i, value := range values {
+		s.Results[i] = result{
+			User:   internal.User{ID: strconv.Itoa(i), Name: "User " + strconv.Itoa(i)},
+			Sizing: value,
+		}
+
+		if value == "" {
+			s.Results[i].inactive = true
+			s.Results[i].Sizing = "3"
+		}
+	}
+
+	return s
+}
+
+func TestState_Stats(t *testing.T) {
+	cards := []string{"1", "2", "3", "5", "8", "﹖"}
+
+	tests := []struct {
+		name  string
+		votes []string
+		want  stats
+	}{
+		{name: "no vote", want: stats{}},
+		{
+			name:  "unknown size ignored",
+			votes: []string{"2", "﹖"},
+			want:  stats{Count: 1, Average: 2, Median: 2, Min: "2", Max: "2", Mode: []string{"2"}, Suggested: "2"},
+		},
+		{
+			name:  "inactive user ignored",
+			votes: []string{"1", ""},
+			want:  stats{Count: 1, Average: 1, Median: 1, Min: "1", Max: "1", Mode: []string{"1"}, Suggested: "1"},
+		},
+		{
+			name:  "odd count",
+			votes: []string{"8", "1", "2"},
+			want: stats{
+				Count: 3, Average: 8.0 / 3, Median: 2, Min: "1", Max: "8", Mode: []string{"1", "2", "8"}, Suggested: "2",
+				Disagreement: true,
+			},
+		},
+		{
+			name:  "even count between cards",
+			votes: []string{"1", "2"},
+			want:  stats{Count: 2, Average: 1.5, Median: 1.5, Min: "1", Max: "2", Mode: []string{"1", "2"}, Suggested: "2"},
+		},
+		{
+			name:  "even count on a card",
+			votes: []string{"5", "3", "2", "3"},
+			want:  stats{Count: 4, Average: 3, Median: 3, Min: "2", Max: "5", Mode: []string{"3"}, Suggested: "3"},
+		},
+		{
+			name:  "mode ties",
+			votes: []string{"5", "1", "5", "1", "3"},
+			want: stats{
+				Count: 5, Average: 2.6, Median: 3, Min: "1", Max: "5", Mode: []string{"1", "5"}, Suggested: "3",
+				Disagreement: true,
+			},
+		},
+		{
+			name:  "consensus",
+			votes: []string{"3", "3"},
+			want: stats{
+				Count: 2, Average: 3, Median: 3, Min: "3", Max: "3", Mode: []string{"3"}, Suggested: "3", Consensus: true,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := withVotes(testState(cards...), tt.votes...).Stats(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Stats = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestState_Stats_sameWeight(t *testing.T) {
+	zero, one := 0.0, 1.0
+	s := &state{
+		Decks: []deck.Deck{{ID: 1, Name: "Test", Cards: []deck.Card{
+			{Value: "0", Weight: &zero},
+			{Value: "☕", Weight: &zero},
+			{Value: "1", Weight: &one},
+		}}},
+		Ticket: &ticket{DeckID: 1, Round: 1},
+	}
+
+	got := withVotes(s, "☕", "1", "☕").Stats()
+	want := stats{Count: 3, Average: 1.0 / 3, Median: 0, Min: "☕", Max: "1", Mode: []string{"☕"}, Suggested: "☕"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Stats = %+v, want %+v", got, want)
+	}
+
+	if got := withVotes(s, "☕", "☕").Stats(); got.Suggested != "☕" || !got.Consensus {
+		t.Errorf("Stats = %+v, want a consensus on ☕", got)
+	}
+}
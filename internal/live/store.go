@@ -0,0 +1,204 @@
+package live
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/MartyHub/size-it/internal"
+	"github.com/MartyHub/size-it/internal/db"
+	"github.com/MartyHub/size-it/internal/db/sqlc"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Store persists snapshots of live session states, so that they survive server restarts
+// and are shared by every replica.
+type Store interface {
+	// Load returns internal.ErrNotFound if no snapshot has been saved for the session.
+	Load(ctx context.Context, sessionID string) ([]byte, error)
+	// Update replaces the snapshot of the session by the one returned by fn, given the current one (nil if none).
+	// Concurrent updates of the same session are serialized, then other replicas are notified by replicaID.
+	// The queries given to fn run within the same transaction as the snapshot, they are nil without a database.
+	Update(
+		ctx context.Context,
+		sessionID, replicaID string,
+		fn func(queries *sqlc.Queries, data []byte) ([]byte, error),
+	) error
+}
+
+type (
+	RepositoryStore struct {
+		clk  internal.Clock
+		repo *db.Repository
+	}
+
+	snapshot struct {
+		Closed          bool                 `json:"closed,omitempty"`
+		FacilitatorID   string               `json:"facilitatorId,omitempty"`
+		AutoReveal      bool                 `json:"autoReveal"`
+		AutoRevealDelay time.Duration        `json:"autoRevealDelay"`
+		RevealAt        time.Time            `json:"revealAt"`
+		Ticket          ticket               `json:"ticket"`
+		Results         []resultSnapshot     `json:"results"`
+		Removed         map[string]time.Time `json:"removed,omitempty"`
+		Show            bool                 `json:"show"`
+		Timer           *timerSnapshot       `json:"timer,omitempty"`
+	}
+
+	resultSnapshot struct {
+		User     internal.User `json:"user"`
+		Replica  string        `json:"replica,omitempty"`
+		Inactive bool          `json:"inactive"`
+		Sizing   string        `json:"sizing,omitempty"`
+		VotedAt  time.Time     `json:"votedAt,omitempty"`
+	}
+)
+
+func NewRepositoryStore(clk internal.Clock, repo *db.Repository) *RepositoryStore {
+	return &RepositoryStore{
+		clk:  clk,
+		repo: repo,
+	}
+}
+
+func (store *RepositoryStore) Load(ctx context.Context, sessionID string) ([]byte, error) {
+	data, err := store.repo.LiveState(ctx, sessionID)
+	if err != nil {
+		if db.IsErrNoRows(err) {
+			return nil, fmt.Errorf("%w: live state of session %s", internal.ErrNotFound, sessionID)
+		}
+
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (store *RepositoryStore) Update(
+	ctx context.Context,
+	sessionID, replicaID string,
+	fn func(queries *sqlc.Queries, data []byte) ([]byte, error),
+) error {
+	return store.repo.InTx(ctx, func(queries *sqlc.Queries) error {
+		data, err := queries.LiveStateForUpdate(ctx, sessionID)
+		if err != nil && !db.IsErrNoRows(err) {
+			return err
+		}
+
+		if data, err = fn(queries, data); err != nil {
+			return err
+		}
+
+		if err = queries.SaveLiveState(ctx, sqlc.SaveLiveStateParams{
+			SessionID: sessionID,
+			Data:      data,
+			UpdatedAt: pgtype.Timestamp{Time: store.clk.Now(), Valid: true},
+		}); err != nil {
+			return err
+		}
+
+		// delivered to listeners once the transaction is committed
+		return queries.NotifyLiveState(ctx, sqlc.NotifyLiveStateParams{
+			Channel: channel(sessionID),
+			Payload: replicaID,
+		})
+	})
+}
+
+func (s *state) snapshot() snapshot {
+	res := snapshot{
+		Closed:          s.closed,
+		FacilitatorID:   s.facilitatorID,
+		AutoReveal:      s.AutoReveal,
+		AutoRevealDelay: s.AutoRevealDelay,
+		Ticket:          *s.Ticket,
+		Results:         make([]resultSnapshot, len(s.Results)),
+		Removed:         s.removed,
+		Show:            s.Show,
+	}
+
+	if s.Countdown != nil {
+		res.RevealAt = s.Countdown.end
+	}
+
+	if s.Timer != nil {
+		res.Timer = &timerSnapshot{
+			End:      s.Timer.end,
+			Duration: s.Timer.Duration,
+			Reveal:   s.Timer.Reveal,
+		}
+	}
+
+	for i, r := range s.Results {
+		res.Results[i] = resultSnapshot{
+			User:     r.User,
+			Replica:  r.replica,
+			Inactive: r.inactive,
+			Sizing:   r.Sizing,
+			VotedAt:  r.votedAt,
+		}
+	}
+
+	return res
+}
+
+// merge applies a snapshot saved by any replica to the state.
+// Users connected to this replica keep their events, unless they connected to another replica since,
+// or have been removed.
+func (s *state) merge(snp snapshot, replicaID string) {
+	tck := snp.Ticket
+
+	s.Ticket = &tck
+	s.Show = snp.Show
+
+	if snp.FacilitatorID != "" {
+		s.facilitatorID = snp.FacilitatorID
+		s.AutoReveal = snp.AutoReveal
+		s.AutoRevealDelay = snp.AutoRevealDelay
+	}
+
+	s.removed = snp.Removed
+
+	for _, r := range snp.Results {
+		i := slices.IndexFunc(s.Results, func(res result) bool { return res.User.Equals(r.User) })
+		if i < 0 {
+			s.Results = append(s.Results, result{})
+			i = len(s.Results) - 1
+		}
+
+		res := &s.Results[i]
+
+		if r.Replica != replicaID && res.local() {
+			s.closeEvents(res.events)
+
+			res.events = nil
+			res.maxInactiveTime = time.Time{}
+		}
+
+		res.inactive = r.Inactive || r.Replica == ""
+		res.replica = r.Replica
+		res.votedAt = r.VotedAt
+		res.User = r.User
+		res.Sizing = r.Sizing
+	}
+
+	s.dropRemoved()
+
+	if s.Deck().ID == 0 {
+		s.switchDeck(s.Decks[0].ID)
+	}
+}
+
+// deactivateReplicas marks as inactive the users connected to replicas which are not alive anymore.
+func (s *state) deactivateReplicas(alive []string) {
+	for i, res := range s.Results {
+		if !slices.Contains(alive, res.replica) {
+			s.Results[i].inactive = true
+		}
+	}
+}
+
+func channel(sessionID string) string {
+	return channelPrefix + sessionID
+}
@@ -0,0 +1,53 @@
+package live
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/MartyHub/size-it/internal"
+	"github.com/MartyHub/size-it/internal/db/sqlc"
+)
+
+// SummaryResolver fetches the summary of the issue at the given URL, on behalf of a team.
+// It returns an empty summary if it can't tell.
+type SummaryResolver interface {
+	Summary(ctx context.Context, team, url string) (string, error)
+}
+
+// SetSummaryResolver makes UpdateTicket fill the summary of tickets from their URL.
+// It must be called before the server starts.
+func (svc *Service) SetSummaryResolver(resolver SummaryResolver) {
+	svc.resolver = resolver
+}
+
+// resolveSummary fills the summary of the ticket, unless its URL changed or a summary has been typed meanwhile.
+func (svc *Service) resolveSummary(sessionID, team, url string) {
+	ctx := context.Background()
+
+	summary, err := svc.resolver.Summary(ctx, team, url)
+	if err != nil {
+		slog.Warn("Failed to resolve ticket summary",
+			slog.String(internal.LogKeySession, sessionID),
+			slog.String(internal.LogKeyURI, url),
+			slog.String(internal.LogKeyError, err.Error()),
+		)
+
+		return
+	}
+
+	if summary == "" {
+		return
+	}
+
+	if err = svc.update(ctx, sessionID, func(s *state, _ *sqlc.Queries) error {
+		if s.Ticket.URL != url || s.Ticket.Summary != "" {
+			return nil
+		}
+
+		s.Ticket.Summary = truncate(summary, maxSummarySize)
+
+		return svc.ntf.notifyTicket(sessionID, s, allActiveUsers)
+	}); err != nil {
+		internal.LogError("Failed to update ticket summary", err)
+	}
+}
@@ -0,0 +1,222 @@
+package live
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/MartyHub/size-it/internal"
+	"github.com/MartyHub/size-it/internal/db/sqlc"
+)
+
+type (
+	// timer limits the time spent on a ticket, it may reveal votes once expired.
+	timer struct {
+		end       time.Time
+		stop      chan struct{}
+		Duration  time.Duration
+		Remaining int
+		Reveal    bool
+	}
+
+	timerSnapshot struct {
+		End      time.Time     `json:"end"`
+		Duration time.Duration `json:"duration"`
+		Reveal   bool          `json:"reveal"`
+	}
+)
+
+func (tm *timer) update(now time.Time) {
+	tm.Remaining = max(0, int(math.Ceil(tm.end.Sub(now).Seconds())))
+}
+
+func (tm *timer) Expired() bool {
+	return tm.Remaining == 0
+}
+
+// Text formats the remaining time as minutes and seconds.
+func (tm *timer) Text() string {
+	return fmt.Sprintf("%d:%02d", tm.Remaining/60, tm.Remaining%60) //nolint:mnd
+}
+
+// StartTimer starts a timer for the current ticket, replacing the running one if any, facilitator only.
+func (svc *Service) StartTimer(
+	ctx context.Context,
+	sessionID string,
+	duration time.Duration,
+	reveal bool,
+	usr internal.User,
+) error {
+	return svc.update(ctx, sessionID, func(s *state, _ *sqlc.Queries) error {
+		if err := s.checkFacilitator(sessionID, usr); err != nil {
+			return err
+		}
+
+		slog.Info("Starting timer",
+			slog.String(internal.LogKeySession, sessionID),
+			slog.String("duration", duration.String()),
+			slog.Bool("reveal", reveal),
+		)
+
+		svc.stopTimer(s)
+		svc.startTimer(sessionID, s, timerSnapshot{
+			End:      svc.clk.Now().Add(duration),
+			Duration: duration,
+			Reveal:   reveal,
+		})
+
+		if err := svc.ntf.notifyControls(sessionID, s, allActiveUsers); err != nil {
+			return err
+		}
+
+		return svc.ntf.notifyTimer(sessionID, s, allActiveUsers)
+	})
+}
+
+// StopTimer cancels the timer, or hides it once expired, facilitator only.
+func (svc *Service) StopTimer(ctx context.Context, sessionID string, usr internal.User) error {
+	return svc.update(ctx, sessionID, func(s *state, _ *sqlc.Queries) error {
+		if err := s.checkFacilitator(sessionID, usr); err != nil {
+			return err
+		}
+
+		return svc.clearTimer(sessionID, s)
+	})
+}
+
+// clearTimer must be called with the session state locked.
+func (svc *Service) clearTimer(sessionID string, s *state) error {
+	if s.Timer == nil {
+		return nil
+	}
+
+	slog.Info("Stopping timer", slog.String(internal.LogKeySession, sessionID))
+
+	svc.stopTimer(s)
+
+	if err := svc.ntf.notifyControls(sessionID, s, allActiveUsers); err != nil {
+		return err
+	}
+
+	return svc.ntf.notifyTimer(sessionID, s, allActiveUsers)
+}
+
+// syncTimer follows the timer of a snapshot, possibly started by another replica.
+// It must be called with the session state locked.
+func (svc *Service) syncTimer(sessionID string, s *state, snp *timerSnapshot) error {
+	if snp == nil {
+		return svc.clearTimer(sessionID, s)
+	}
+
+	if s.Timer != nil && s.Timer.end.Equal(snp.End) {
+		return nil
+	}
+
+	svc.stopTimer(s)
+	svc.startTimer(sessionID, s, *snp)
+
+	if err := svc.ntf.notifyControls(sessionID, s, allActiveUsers); err != nil {
+		return err
+	}
+
+	return svc.ntf.notifyTimer(sessionID, s, allActiveUsers)
+}
+
+// startTimer must be called with the session state locked.
+func (svc *Service) startTimer(sessionID string, s *state, snp timerSnapshot) {
+	s.Timer = &timer{
+		end:      snp.End,
+		stop:     make(chan struct{}),
+		Duration: snp.Duration,
+		Reveal:   snp.Reveal,
+	}
+	s.Timer.update(svc.clk.Now())
+
+	if !s.Timer.Expired() {
+		go svc.runTimer(sessionID, s, s.Timer, svc.clk.NewTicker(time.Second))
+	}
+}
+
+// stopTimer must be called with the session state locked.
+func (svc *Service) stopTimer(s *state) {
+	if s.Timer == nil {
+		return
+	}
+
+	if !s.Timer.Expired() {
+		close(s.Timer.stop)
+	}
+
+	s.Timer = nil
+}
+
+func (svc *Service) runTimer(sessionID string, s *state, tm *timer, ticker internal.Ticker) {
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-svc.done:
+			return
+		case <-tm.stop:
+			return
+		case <-ticker.C():
+			done, err := svc.tickTimer(sessionID, s, tm)
+			if err != nil {
+				internal.LogError("Failed to update timer", err)
+			}
+
+			if done {
+				return
+			}
+		}
+	}
+}
+
+// tickTimer broadcasts the remaining time, then reveals votes once expired if requested.
+func (svc *Service) tickTimer(sessionID string, s *state, tm *timer) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer svc.flush(sessionID, s)
+
+	if s.Timer != tm {
+		// stopped in the meantime
+		return true, nil
+	}
+
+	tm.update(svc.clk.Now())
+
+	if !tm.Expired() {
+		return false, svc.ntf.notifyTimer(sessionID, s, allActiveUsers)
+	}
+
+	return true, svc.apply(context.Background(), sessionID, s, func(_ *sqlc.Queries) error {
+		if s.Timer != tm {
+			// stopped by another replica in the meantime
+			return nil
+		}
+
+		slog.Info("Timer expired", slog.String(internal.LogKeySession, sessionID))
+
+		if err := svc.ntf.notifyControls(sessionID, s, allActiveUsers); err != nil {
+			return err
+		}
+
+		if err := svc.ntf.notifyTimer(sessionID, s, allActiveUsers); err != nil {
+			return err
+		}
+
+		if !tm.Reveal || s.Show {
+			return nil
+		}
+
+		if err := svc.stopCountdown(sessionID, s); err != nil {
+			return err
+		}
+
+		s.Show = true
+
+		return svc.ntf.notifyResults(sessionID, s)
+	})
+}
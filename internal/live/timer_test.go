@@ -0,0 +1,121 @@
+package live
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MartyHub/size-it/internal"
+)
+
+// none fails if an event of the given kind is sent for a while.
+func none(t *testing.T, events chan Event, kind string) {
+	t.Helper()
+
+	timeout := time.After(100 * time.Millisecond)
+
+	for {
+		select {
+		case evt := <-events:
+			if evt.Kind == kind {
+				t.Fatalf("unexpected %s: %s", kind, evt.Data)
+			}
+		case <-timeout:
+			return
+		}
+	}
+}
+
+// tick advances the clock by a second, returning the next event of the given kind.
+func tick(t *testing.T, clk *internal.FixedClock, events chan Event, kind string) string {
+	t.Helper()
+
+	clk.Advance(time.Second)
+
+	return string(next(t, events, kind).Data)
+}
+
+func startTimer(t *testing.T, svc *Service, events chan Event, duration time.Duration, reveal bool) {
+	t.Helper()
+
+	if err := svc.StartTimer(context.Background(), testSessionID, duration, reveal, alice); err != nil {
+		t.Fatal(err)
+	}
+
+	next(t, events, "timer")
+}
+
+func shown(svc *Service) bool {
+	s := svc.stateBySessionID[testSessionID]
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.Show
+}
+
+func TestService_StartTimer(t *testing.T) {
+	clk := internal.NewFixedClock(time.Now())
+	svc := newTestService(t, clk)
+	events := join(t, svc, alice, testBufferSize)
+
+	startTimer(t, svc, events, 3*time.Second, false)
+
+	for _, want := range []string{"0:02", "0:01", "0:00"} {
+		if got := tick(t, clk, events, "timer"); !strings.HasSuffix(got, want) {
+			t.Errorf("timer = %s, want %s", got, want)
+		}
+	}
+
+	// expired
+	clk.Advance(time.Second)
+	none(t, events, "timer")
+
+	if shown(svc) {
+		t.Error("votes revealed")
+	}
+}
+
+func TestService_StartTimer_reveals(t *testing.T) {
+	clk := internal.NewFixedClock(time.Now())
+	svc := newTestService(t, clk)
+	events := join(t, svc, alice, testBufferSize)
+
+	startTimer(t, svc, events, 2*time.Second, true)
+	tick(t, clk, events, "timer")
+
+	if shown(svc) {
+		t.Error("votes revealed before expiry")
+	}
+
+	tick(t, clk, events, "results")
+
+	if !shown(svc) {
+		t.Error("votes not revealed once expired")
+	}
+}
+
+func TestService_StopTimer(t *testing.T) {
+	clk := internal.NewFixedClock(time.Now())
+	svc := newTestService(t, clk)
+	events := join(t, svc, alice, testBufferSize)
+
+	startTimer(t, svc, events, 2*time.Second, true)
+	tick(t, clk, events, "timer")
+
+	if err := svc.StopTimer(context.Background(), testSessionID, alice); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := next(t, events, "timer"); string(got.Data) != "components/timer.gohtml" {
+		t.Errorf("timer = %s, want none", got.Data)
+	}
+
+	clk.Advance(5 * time.Second)
+	none(t, events, "timer")
+
+	if shown(svc) {
+		t.Error("votes revealed by a stopped timer")
+	}
+}
@@ -0,0 +1,92 @@
+package live
+
+import (
+	"context"
+	"slices"
+
+	"github.com/MartyHub/size-it/internal"
+	"github.com/MartyHub/size-it/internal/deck"
+)
+
+type (
+	// View is the state of a session as seen by a user: votes of others are hidden until revealed.
+	View struct {
+		Ticket          ticket     `json:"ticket"`
+		Deck            deck.Deck  `json:"deck"`
+		FacilitatorID   string     `json:"facilitatorId"`
+		AutoReveal      bool       `json:"autoReveal"`
+		AutoRevealDelay int        `json:"autoRevealDelay"`
+		Countdown       int        `json:"countdown,omitempty"`
+		Timer           *int       `json:"timer,omitempty"`
+		Show            bool       `json:"show"`
+		Votes           []VoteView `json:"votes"`
+		Stats           *stats     `json:"stats,omitempty"`
+
+		Backlog []BacklogItem `json:"backlog"`
+	}
+
+	VoteView struct {
+		User  internal.User `json:"user"`
+		Voted bool          `json:"voted"`
+		Value string        `json:"value,omitempty"`
+	}
+)
+
+func (svc *Service) View(ctx context.Context, sessionID string, usr internal.User) (View, error) {
+	s, err := svc.state(ctx, sessionID)
+	if err != nil {
+		return View{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.view(usr), nil
+}
+
+func (s *state) view(usr internal.User) View {
+	res := View{
+		Ticket:          *s.Ticket,
+		Deck:            s.Deck(),
+		FacilitatorID:   s.facilitatorID,
+		AutoReveal:      s.AutoReveal,
+		AutoRevealDelay: int(s.AutoRevealDelay.Seconds()),
+		Show:            s.Show,
+		Votes:           make([]VoteView, 0, len(s.Results)),
+		Backlog:         slices.Clone(s.Backlog),
+	}
+
+	if s.Countdown != nil {
+		res.Countdown = s.Countdown.Remaining
+	}
+
+	if s.Timer != nil {
+		remaining := s.Timer.Remaining
+		res.Timer = &remaining
+	}
+
+	for _, r := range s.Results {
+		if r.inactive {
+			continue
+		}
+
+		vote := VoteView{
+			User:  r.User,
+			Voted: r.Sizing != "",
+		}
+
+		if s.Show || r.User.Equals(usr) {
+			vote.Value = r.Sizing
+		}
+
+		res.Votes = append(res.Votes, vote)
+	}
+
+	if s.Show {
+		stats := s.Stats()
+
+		res.Stats = &stats
+	}
+
+	return res
+}
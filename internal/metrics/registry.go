@@ -0,0 +1,352 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const labelSeparator = "\xff"
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`) //nolint:gochecknoglobals
+
+// DefaultBuckets are suited to HTTP request durations, in seconds.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10} //nolint:gochecknoglobals
+
+type (
+	// Registry collects metrics, written in the Prometheus text exposition format.
+	Registry struct {
+		mu      sync.Mutex
+		metrics []metric
+	}
+
+	metric interface {
+		write(w *bufio.Writer)
+	}
+
+	// vec holds the series of a metric by label values.
+	vec[T any] struct {
+		mu     sync.Mutex
+		name   string
+		help   string
+		kind   string
+		labels []string
+		series map[string]*T
+		create func() *T
+	}
+
+	Counter struct {
+		*vec[value]
+	}
+
+	Gauge struct {
+		*vec[value]
+	}
+
+	Histogram struct {
+		*vec[histogram]
+		buckets []float64
+	}
+
+	// funcMetric is computed when metrics are written.
+	funcMetric struct {
+		name string
+		help string
+		kind string
+		fn   func() float64
+	}
+
+	value struct {
+		mu sync.Mutex
+		v  float64
+	}
+
+	histogram struct {
+		mu     sync.Mutex
+		counts []uint64
+		count  uint64
+		sum    float64
+	}
+)
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (reg *Registry) Counter(name, help string, labels ...string) *Counter {
+	res := &Counter{vec: newVec(name, help, "counter", labels, func() *value { return &value{} })}
+
+	reg.register(res)
+
+	return res
+}
+
+func (reg *Registry) Gauge(name, help string, labels ...string) *Gauge {
+	res := &Gauge{vec: newVec(name, help, "gauge", labels, func() *value { return &value{} })}
+
+	reg.register(res)
+
+	return res
+}
+
+// CounterFunc registers a counter whose value is computed when metrics are written.
+func (reg *Registry) CounterFunc(name, help string, fn func() float64) {
+	reg.register(&funcMetric{name: name, help: help, kind: "counter", fn: fn})
+}
+
+// GaugeFunc registers a gauge whose value is computed when metrics are written.
+func (reg *Registry) GaugeFunc(name, help string, fn func() float64) {
+	reg.register(&funcMetric{name: name, help: help, kind: "gauge", fn: fn})
+}
+
+func (reg *Registry) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+
+	res := &Histogram{
+		vec: newVec(name, help, "histogram", labels, func() *histogram {
+			return &histogram{counts: make([]uint64, len(buckets))}
+		}),
+		buckets: buckets,
+	}
+
+	reg.register(res)
+
+	return res
+}
+
+func (reg *Registry) Write(w io.Writer) error {
+	reg.mu.Lock()
+	metrics := slices.Clone(reg.metrics)
+	reg.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+
+	for _, m := range metrics {
+		m.write(bw)
+	}
+
+	return bw.Flush()
+}
+
+func (reg *Registry) register(m metric) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+
+	reg.metrics = append(reg.metrics, m)
+}
+
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c *Counter) Add(v float64, labelValues ...string) {
+	c.with(labelValues).add(v)
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	writeValues(w, c.vec)
+}
+
+func (g *Gauge) Inc(labelValues ...string) {
+	g.Add(1, labelValues...)
+}
+
+func (g *Gauge) Dec(labelValues ...string) {
+	g.Add(-1, labelValues...)
+}
+
+func (g *Gauge) Add(v float64, labelValues ...string) {
+	g.with(labelValues).add(v)
+}
+
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	val := g.with(labelValues)
+
+	val.mu.Lock()
+	defer val.mu.Unlock()
+
+	val.v = v
+}
+
+func (g *Gauge) write(w *bufio.Writer) {
+	writeValues(w, g.vec)
+}
+
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	hst := h.with(labelValues)
+
+	hst.mu.Lock()
+	defer hst.mu.Unlock()
+
+	if i, _ := slices.BinarySearch(h.buckets, v); i < len(h.buckets) {
+		hst.counts[i]++
+	}
+
+	hst.count++
+	hst.sum += v
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.writeHeader(w)
+
+	h.each(func(labelValues []string, hst *histogram) {
+		hst.mu.Lock()
+		defer hst.mu.Unlock()
+
+		var cumulative uint64
+
+		for i, bucket := range h.buckets {
+			cumulative += hst.counts[i]
+
+			writeSample(w, h.name+"_bucket", append(h.labelPairs(labelValues), "le", formatFloat(bucket)), float64(cumulative))
+		}
+
+		writeSample(w, h.name+"_bucket", append(h.labelPairs(labelValues), "le", "+Inf"), float64(hst.count))
+		writeSample(w, h.name+"_sum", h.labelPairs(labelValues), hst.sum)
+		writeSample(w, h.name+"_count", h.labelPairs(labelValues), float64(hst.count))
+	})
+}
+
+func (m *funcMetric) write(w *bufio.Writer) {
+	writeHeader(w, m.name, m.help, m.kind)
+	writeSample(w, m.name, nil, m.fn())
+}
+
+func newVec[T any](name, help, kind string, labels []string, create func() *T) *vec[T] {
+	return &vec[T]{
+		name:   name,
+		help:   help,
+		kind:   kind,
+		labels: labels,
+		series: make(map[string]*T),
+		create: create,
+	}
+}
+
+func (v *vec[T]) with(labelValues []string) *T {
+	if len(labelValues) != len(v.labels) {
+		panic(fmt.Sprintf("metric %s expects %d label values, got %d", v.name, len(v.labels), len(labelValues)))
+	}
+
+	key := strings.Join(labelValues, labelSeparator)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	res, found := v.series[key]
+	if !found {
+		res = v.create()
+		v.series[key] = res
+	}
+
+	return res
+}
+
+// each calls fn for every series, sorted by label values.
+func (v *vec[T]) each(fn func(labelValues []string, series *T)) {
+	v.mu.Lock()
+	keys := make([]string, 0, len(v.series))
+
+	for key := range v.series {
+		keys = append(keys, key)
+	}
+
+	series := make([]*T, len(keys))
+
+	slices.Sort(keys)
+
+	for i, key := range keys {
+		series[i] = v.series[key]
+	}
+	v.mu.Unlock()
+
+	for i, key := range keys {
+		var labelValues []string
+
+		if len(v.labels) > 0 {
+			labelValues = strings.Split(key, labelSeparator)
+		}
+
+		fn(labelValues, series[i])
+	}
+}
+
+func (v *vec[T]) labelPairs(labelValues []string) []string {
+	res := make([]string, 0, 2*len(labelValues)) //nolint:mnd
+
+	for i, label := range v.labels {
+		res = append(res, label, labelValues[i])
+	}
+
+	return res
+}
+
+func (v *vec[T]) writeHeader(w *bufio.Writer) {
+	writeHeader(w, v.name, v.help, v.kind)
+}
+
+func writeValues(w *bufio.Writer, v *vec[value]) {
+	v.writeHeader(w)
+
+	v.each(func(labelValues []string, val *value) {
+		writeSample(w, v.name, v.labelPairs(labelValues), val.get())
+	})
+}
+
+func (val *value) add(v float64) {
+	val.mu.Lock()
+	defer val.mu.Unlock()
+
+	val.v += v
+}
+
+func (val *value) get() float64 {
+	val.mu.Lock()
+	defer val.mu.Unlock()
+
+	return val.v
+}
+
+func writeHeader(w *bufio.Writer, name, help, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, strings.ReplaceAll(help, "\n", " "), name, kind)
+}
+
+// writeSample writes a line like: name{label="value",...} 1.5.
+func writeSample(w *bufio.Writer, name string, labelPairs []string, v float64) {
+	w.WriteString(name)
+
+	if len(labelPairs) > 0 {
+		w.WriteByte('{')
+
+		for i := 0; i < len(labelPairs); i += 2 {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+
+			fmt.Fprintf(w, `%s="%s"`, labelPairs[i], labelEscaper.Replace(labelPairs[i+1]))
+		}
+
+		w.WriteByte('}')
+	}
+
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(v))
+	w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
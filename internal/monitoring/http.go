@@ -3,25 +3,59 @@ package monitoring
 import (
 	"net/http"
 
+	"github.com/MartyHub/size-it/internal/metrics"
+	"github.com/MartyHub/size-it/internal/openapi"
 	"github.com/MartyHub/size-it/internal/server"
 	"github.com/labstack/echo/v4"
 )
 
+const tagMonitoring = "monitoring"
+
 func Register(srv *server.Server) {
-	hdl := &handler{svc: newService(srv.Clk, srv.Repo)}
+	hdl := &handler{
+		reg: srv.Metrics,
+		svc: newService(srv.Clk, srv.Done(), srv.Renderer(), srv.Repo),
+	}
+
+	srv.OpenAPI.SetVersion(Version)
+
+	srv.API(http.MethodGet, "/api/v1/health", hdl.ready, openapi.Route{
+		Summary: "Health of the application, same as readiness",
+		Tags:    []string{tagMonitoring},
+		Output:  HealthOutput{},
+	})
+	srv.API(http.MethodGet, "/api/v1/health/live", hdl.live, openapi.Route{
+		Summary: "Liveness of the application, 503 if it should be restarted",
+		Tags:    []string{tagMonitoring},
+		Output:  HealthOutput{},
+	})
+	srv.API(http.MethodGet, "/api/v1/health/ready", hdl.ready, openapi.Route{
+		Summary: "Readiness of the application and its dependencies, 503 if it should not receive traffic",
+		Tags:    []string{tagMonitoring},
+		Output:  HealthOutput{},
+	})
+	srv.API(http.MethodGet, "/api/v1/info", hdl.info, openapi.Route{
+		Summary: "Versions of the application and its dependencies",
+		Tags:    []string{tagMonitoring},
+		Output:  InfoOutput{},
+	})
 
-	srv.GET("/api/v1/health", hdl.health)
-	srv.GET("/api/v1/info", hdl.info)
+	registerDatabaseMetrics(srv.Metrics, srv.Repo)
+
+	srv.GET("/metrics", hdl.metrics)
 }
 
 type handler struct {
+	reg *metrics.Registry
 	svc *service
 }
 
-func (hdl *handler) health(c echo.Context) error {
-	output := hdl.svc.health(c.Request().Context())
+func (hdl *handler) live(c echo.Context) error {
+	return health(c, hdl.svc.live(c.Request().Context()))
+}
 
-	return c.JSON(http.StatusOK, output)
+func (hdl *handler) ready(c echo.Context) error {
+	return health(c, hdl.svc.ready(c.Request().Context()))
 }
 
 func (hdl *handler) info(c echo.Context) error {
@@ -32,3 +66,18 @@ func (hdl *handler) info(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, output)
 }
+
+func (hdl *handler) metrics(c echo.Context) error {
+	c.Response().Header().Set(echo.HeaderContentType, mimePrometheus)
+	c.Response().WriteHeader(http.StatusOK)
+
+	return hdl.reg.Write(c.Response())
+}
+
+func health(c echo.Context, output HealthOutput) error {
+	if !output.up() {
+		return c.JSON(http.StatusServiceUnavailable, output)
+	}
+
+	return c.JSON(http.StatusOK, output)
+}
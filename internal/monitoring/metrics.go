@@ -0,0 +1,35 @@
+package monitoring
+
+import (
+	"github.com/MartyHub/size-it/internal/db"
+	"github.com/MartyHub/size-it/internal/metrics"
+)
+
+const mimePrometheus = "text/plain; version=0.0.4; charset=utf-8"
+
+func registerDatabaseMetrics(reg *metrics.Registry, repo *db.Repository) {
+	reg.GaugeFunc("size_it_db_pool_acquired_connections", "Number of connections currently acquired from the pool.",
+		func() float64 { return float64(repo.Stat().AcquiredConns()) },
+	)
+	reg.GaugeFunc("size_it_db_pool_idle_connections", "Number of idle connections in the pool.",
+		func() float64 { return float64(repo.Stat().IdleConns()) },
+	)
+	reg.GaugeFunc("size_it_db_pool_total_connections", "Number of connections in the pool.",
+		func() float64 { return float64(repo.Stat().TotalConns()) },
+	)
+	reg.GaugeFunc("size_it_db_pool_max_connections", "Maximum size of the pool.",
+		func() float64 { return float64(repo.Stat().MaxConns()) },
+	)
+	reg.CounterFunc("size_it_db_pool_acquire_total", "Cumulative count of successful acquires from the pool.",
+		func() float64 { return float64(repo.Stat().AcquireCount()) },
+	)
+	reg.CounterFunc("size_it_db_pool_acquire_duration_seconds_total", "Total duration of successful acquires from the pool.",
+		func() float64 { return repo.Stat().AcquireDuration().Seconds() },
+	)
+	reg.CounterFunc("size_it_db_pool_empty_acquire_total", "Cumulative count of acquires that waited for a connection.",
+		func() float64 { return float64(repo.Stat().EmptyAcquireCount()) },
+	)
+	reg.GaugeFunc("size_it_db_migration_version", "Version of the database schema.",
+		func() float64 { return float64(repo.MigrationVersion()) },
+	)
+}
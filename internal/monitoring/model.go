@@ -5,6 +5,11 @@ import (
 	"runtime/debug"
 )
 
+const (
+	statusUp   = "UP"
+	statusDown = "DOWN"
+)
+
 // Version is injected during the build.
 var Version = "unknown" //nolint:gochecknoglobals
 
@@ -31,8 +36,15 @@ type (
 	}
 
 	HealthOutput struct {
+		Status string                 `json:"status"`
+		Uptime string                 `json:"uptime"`
+		Checks map[string]CheckOutput `json:"checks,omitempty"`
+	}
+
+	CheckOutput struct {
 		Status string `json:"status"`
-		Uptime string `json:"uptime"`
+		Detail string `json:"detail,omitempty"`
+		Error  string `json:"error,omitempty"`
 	}
 )
 
@@ -59,3 +71,7 @@ func newApplicationInfo() ApplicationInfoOutput {
 func newGoInfo() GoInfoOutput {
 	return GoInfoOutput{Version: runtime.Version()}
 }
+
+func (output HealthOutput) up() bool {
+	return output.Status == statusUp
+}
@@ -2,39 +2,124 @@ package monitoring
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"time"
 
 	"github.com/MartyHub/size-it/internal"
 	"github.com/MartyHub/size-it/internal/db"
+	"github.com/labstack/echo/v4"
 )
 
-type service struct {
-	clk       internal.Clock
-	repo      *db.Repository
-	startTime time.Time
-}
+const checkTimeout = 2 * time.Second
+
+var errShuttingDown = errors.New("server is shutting down")
+
+type (
+	service struct {
+		clk       internal.Clock
+		done      <-chan struct{}
+		rdr       echo.Renderer
+		repo      *db.Repository
+		startTime time.Time
+	}
 
-func newService(clk internal.Clock, repo *db.Repository) *service {
+	// check returns an optional detail, or an error if the component is down.
+	check func(ctx context.Context) (string, error)
+)
+
+func newService(clk internal.Clock, done <-chan struct{}, rdr echo.Renderer, repo *db.Repository) *service {
 	return &service{
 		clk:       clk,
+		done:      done,
+		rdr:       rdr,
 		repo:      repo,
 		startTime: clk.Now(),
 	}
 }
 
-func (svc *service) health(ctx context.Context) HealthOutput {
+// live tells whether the process is able to serve requests at all, regardless of its dependencies.
+func (svc *service) live(ctx context.Context) HealthOutput {
+	return svc.health(ctx, map[string]check{
+		"renderer": svc.checkRenderer,
+	})
+}
+
+// ready tells whether requests should be routed to this replica.
+func (svc *service) ready(ctx context.Context) HealthOutput {
+	return svc.health(ctx, map[string]check{
+		"database":  svc.checkDatabase,
+		"migration": svc.checkMigration,
+		"renderer":  svc.checkRenderer,
+		"shutdown":  svc.checkShutdown,
+	})
+}
+
+func (svc *service) health(ctx context.Context, checks map[string]check) HealthOutput {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
 	res := HealthOutput{
-		Status: "UP",
+		Status: statusUp,
 		Uptime: svc.clk.Now().Sub(svc.startTime).String(),
+		Checks: make(map[string]CheckOutput, len(checks)),
 	}
 
-	if err := svc.repo.Ping(ctx); err != nil {
-		res.Status = err.Error()
+	for name, chk := range checks {
+		output := CheckOutput{Status: statusUp}
+
+		detail, err := chk(ctx)
+		if err != nil {
+			output.Status = statusDown
+			output.Error = err.Error()
+			res.Status = statusDown
+		}
+
+		output.Detail = detail
+		res.Checks[name] = output
 	}
 
 	return res
 }
 
+func (svc *service) checkDatabase(ctx context.Context) (string, error) {
+	return "", svc.repo.Ping(ctx)
+}
+
+func (svc *service) checkMigration(ctx context.Context) (string, error) {
+	expected, err := db.LatestMigrationVersion()
+	if err != nil {
+		return "", err
+	}
+
+	version, err := svc.repo.SchemaVersion(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	detail := fmt.Sprintf("version %d", version)
+
+	if version < expected {
+		return detail, fmt.Errorf("database schema is not migrated to version %d", expected)
+	}
+
+	return detail, nil
+}
+
+func (svc *service) checkRenderer(_ context.Context) (string, error) {
+	return "", svc.rdr.Render(io.Discard, "notFound.gohtml", nil, nil)
+}
+
+func (svc *service) checkShutdown(_ context.Context) (string, error) {
+	select {
+	case <-svc.done:
+		return "", errShuttingDown
+	default:
+		return "", nil
+	}
+}
+
 func (svc *service) info(ctx context.Context) (InfoOutput, error) {
 	dbInfo, err := svc.dbInfo(ctx)
 	if err != nil {
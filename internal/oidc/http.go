@@ -0,0 +1,169 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/MartyHub/size-it/internal"
+	"github.com/MartyHub/size-it/internal/server"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	httpTimeout = 10 * time.Second
+	randomSize  = 32
+	stateCookie = "sizeItAuth"
+	stateMaxAge = 10 * 60 // 10 minutes
+)
+
+// Register adds the OpenID Connect routes, if an issuer is configured.
+func Register(srv *server.Server) {
+	if !srv.Cfg.OIDCEnabled() {
+		return
+	}
+
+	hdl := &handler{
+		cfg:     srv.Cfg,
+		cookies: srv.Cookies,
+		prv:     NewProvider(srv.Cfg, srv.Clk, &http.Client{Timeout: httpTimeout}),
+	}
+
+	srv.GET("/auth/login", hdl.login)
+	srv.GET("/auth/callback", hdl.callback)
+	srv.GET("/auth/logout", hdl.logout)
+}
+
+type handler struct {
+	cfg     internal.Config
+	cookies *internal.CookieCodec
+	prv     *Provider
+}
+
+func (hdl *handler) login(c echo.Context) error {
+	input, err := internal.Bind[LoginInput](c)
+	if err != nil {
+		return err
+	}
+
+	state := authState{Next: hdl.next(input.Next)}
+
+	for _, value := range []*string{&state.State, &state.Nonce, &state.Verifier} {
+		if *value, err = random(); err != nil {
+			return err
+		}
+	}
+
+	authURL, err := hdl.prv.AuthCodeURL(c.Request().Context(), hdl.redirectURL(c), state)
+	if err != nil {
+		return err
+	}
+
+	value, err := hdl.cookies.Seal(state)
+	if err != nil {
+		return err
+	}
+
+	c.SetCookie(&http.Cookie{
+		Name:     stateCookie,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   stateMaxAge,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return c.Redirect(http.StatusFound, authURL)
+}
+
+func (hdl *handler) callback(c echo.Context) error {
+	input, err := internal.Bind[CallbackInput](c)
+	if err != nil {
+		return err
+	}
+
+	if input.Error != "" {
+		return fmt.Errorf("%w: %s %s", internal.ErrUnauthorized, input.Error, input.ErrorDescription)
+	}
+
+	cookie, err := c.Cookie(stateCookie)
+	if err != nil {
+		if errors.Is(err, http.ErrNoCookie) {
+			return fmt.Errorf("%w: login expired, please try again", internal.ErrUnauthorized)
+		}
+
+		return err
+	}
+
+	c.SetCookie(&http.Cookie{Name: stateCookie, Path: "/", MaxAge: -1})
+
+	var state authState
+
+	if err = hdl.cookies.Open(cookie.Value, &state); err != nil {
+		return err
+	}
+
+	if input.State != state.State {
+		return fmt.Errorf("%w: unexpected login state", internal.ErrUnauthorized)
+	}
+
+	ctx := c.Request().Context()
+
+	claims, err := hdl.prv.Exchange(ctx, hdl.redirectURL(c), input.Code, state)
+	if err != nil {
+		return err
+	}
+
+	usr := internal.User{
+		ID:   claims.UserID(),
+		Name: claims.Username(),
+	}
+
+	if previous, err := internal.GetUser(ctx); err == nil && previous.Equals(usr) {
+		usr.Team = previous.Team
+	}
+
+	if err = internal.SetCookie(c, hdl.cookies, usr); err != nil {
+		return err
+	}
+
+	return c.Redirect(http.StatusFound, state.Next)
+}
+
+func (hdl *handler) logout(c echo.Context) error {
+	internal.ClearCookie(c)
+
+	return c.Redirect(http.StatusFound, hdl.next(""))
+}
+
+// next only accepts local paths, not to redirect users to another site.
+func (hdl *handler) next(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.Contains(next, `\`) {
+		return path.Join("/", hdl.cfg.Path)
+	}
+
+	return next
+}
+
+func (hdl *handler) redirectURL(c echo.Context) string {
+	if hdl.cfg.OIDCRedirectURL != "" {
+		return hdl.cfg.OIDCRedirectURL
+	}
+
+	return c.Scheme() + "://" + c.Request().Host + path.Join("/", hdl.cfg.Path, "auth", "callback")
+}
+
+func random() (string, error) {
+	data := make([]byte, randomSize)
+
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
@@ -0,0 +1,191 @@
+package oidc
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/MartyHub/size-it/internal"
+	"github.com/labstack/echo/v4"
+)
+
+func newTestHandler(t *testing.T, iss *mockIssuer) *handler {
+	t.Helper()
+
+	cfg := iss.config()
+	cfg.CookieKeys = []string{strings.Repeat("k", 32)}
+	cfg.OIDCRedirectURL = testRedirectURL
+
+	cookies, err := internal.NewCookieCodec(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &handler{
+		cfg:     cfg,
+		cookies: cookies,
+		prv:     NewProvider(cfg, &internal.UTCClock{}, iss.Client()),
+	}
+}
+
+// serve runs a handler on a request with the given cookies, returning the response.
+func serve(t *testing.T, fn echo.HandlerFunc, target string, cookies ...*http.Cookie) (*httptest.ResponseRecorder, error) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+
+	rec := httptest.NewRecorder()
+
+	return rec, fn(echo.New().NewContext(req, rec))
+}
+
+func responseCookie(rec *httptest.ResponseRecorder, name string) *http.Cookie {
+	for _, cookie := range rec.Result().Cookies() { //nolint:bodyclose
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+
+	return nil
+}
+
+// login starts the flow, and returns the state cookie along with the query of the callback sent by the issuer.
+func login(t *testing.T, hdl *handler, next string) (*http.Cookie, url.Values) {
+	t.Helper()
+
+	rec, err := serve(t, hdl.login, "/auth/login?next="+url.QueryEscape(next))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authURL := rec.Header().Get(echo.HeaderLocation)
+
+	if rec.Code != http.StatusFound || !strings.HasPrefix(authURL, hdl.cfg.OIDCIssuer+"/authorize?") {
+		t.Fatalf("login: %d %s", rec.Code, authURL)
+	}
+
+	cookie := responseCookie(rec, stateCookie)
+	if cookie == nil {
+		t.Fatal("no state cookie")
+	}
+
+	return cookie, authorize(t, authURL)
+}
+
+func TestHandler_login(t *testing.T) {
+	iss := newMockIssuer(t)
+	hdl := newTestHandler(t, iss)
+
+	cookie, query := login(t, hdl, "/sessions/S")
+
+	rec, err := serve(t, hdl.callback, "/auth/callback?"+query.Encode(), cookie)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if location := rec.Header().Get(echo.HeaderLocation); rec.Code != http.StatusFound || location != "/sessions/S" {
+		t.Errorf("callback: %d %s", rec.Code, location)
+	}
+
+	session := responseCookie(rec, internal.CookieName)
+	if session == nil {
+		t.Fatal("no session cookie")
+	}
+
+	var usr internal.User
+
+	if err = hdl.cookies.Open(session.Value, &usr); err != nil {
+		t.Fatal(err)
+	}
+
+	if usr.Name != "Alice" || usr.ID == "" {
+		t.Errorf("unexpected user %+v", usr)
+	}
+}
+
+func TestHandler_callback_rejects(t *testing.T) {
+	iss := newMockIssuer(t)
+	hdl := newTestHandler(t, iss)
+
+	tests := []struct {
+		name  string
+		query func(query url.Values)
+		drop  bool
+	}{
+		{
+			name: "state mismatch",
+			query: func(query url.Values) {
+				query.Set("state", "forged")
+			},
+		},
+		{
+			name: "issuer error",
+			query: func(query url.Values) {
+				query.Set("error", "access_denied")
+			},
+		},
+		{
+			name: "expired login",
+			drop: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cookie, query := login(t, hdl, "/")
+
+			var cookies []*http.Cookie
+
+			if !tt.drop {
+				cookies = append(cookies, cookie)
+			}
+
+			if tt.query != nil {
+				tt.query(query)
+			}
+
+			if _, err := serve(t, hdl.callback, "/auth/callback?"+query.Encode(), cookies...); !errors.Is(err, internal.ErrUnauthorized) {
+				t.Errorf("err = %v, want %v", err, internal.ErrUnauthorized)
+			}
+		})
+	}
+}
+
+func TestHandler_logout(t *testing.T) {
+	hdl := newTestHandler(t, newMockIssuer(t))
+
+	rec, err := serve(t, hdl.logout, "/auth/logout")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cookie := responseCookie(rec, internal.CookieName); cookie == nil || cookie.MaxAge >= 0 {
+		t.Errorf("session cookie not cleared: %+v", cookie)
+	}
+
+	if location := rec.Header().Get(echo.HeaderLocation); rec.Code != http.StatusFound || location != "/" {
+		t.Errorf("logout: %d %s", rec.Code, location)
+	}
+}
+
+func TestHandler_next(t *testing.T) {
+	hdl := &handler{cfg: internal.Config{Path: "/size-it"}}
+
+	for next, want := range map[string]string{
+		"/sessions/S":          "/sessions/S",
+		"https://evil.example": "/size-it",
+		"//evil.example":       "/size-it",
+		`/\evil.example`:       "/size-it",
+	} {
+		if got := hdl.next(next); got != want {
+			t.Errorf("next(%q) = %q, want %q", next, got, want)
+		}
+	}
+}
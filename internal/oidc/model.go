@@ -0,0 +1,93 @@
+package oidc
+
+import (
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/json"
+	"strings"
+)
+
+const maxUsernameSize = 32
+
+type (
+	// Claims are the ID token claims used to identify users.
+	Claims struct {
+		Issuer            string   `json:"iss"`
+		Subject           string   `json:"sub"`
+		Audience          audience `json:"aud"`
+		Expiry            int64    `json:"exp"`
+		Nonce             string   `json:"nonce"`
+		Name              string   `json:"name"`
+		PreferredUsername string   `json:"preferred_username"`
+		Email             string   `json:"email"`
+	}
+
+	// audience is either a single string or an array of strings.
+	audience []string
+
+	discovery struct {
+		Issuer                string `json:"issuer"`
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+		JWKSURI               string `json:"jwks_uri"`
+	}
+
+	tokenOutput struct {
+		IDToken string `json:"id_token"`
+	}
+
+	// authState is kept in a signed cookie between the login and the callback.
+	authState struct {
+		State    string `json:"state"`
+		Nonce    string `json:"nonce"`
+		Verifier string `json:"verifier"`
+		Next     string `json:"next"`
+	}
+
+	LoginInput struct {
+		Next string `query:"next"`
+	}
+
+	CallbackInput struct {
+		Code             string `query:"code"`
+		State            string `query:"state"`
+		Error            string `query:"error"`
+		ErrorDescription string `query:"error_description"`
+	}
+)
+
+func (aud *audience) UnmarshalJSON(data []byte) error {
+	var single string
+
+	if err := json.Unmarshal(data, &single); err == nil {
+		*aud = audience{single}
+
+		return nil
+	}
+
+	return json.Unmarshal(data, (*[]string)(aud))
+}
+
+// UserID derives a stable user ID, with the same size as generated ones, from the issuer and subject.
+func (claims Claims) UserID() string {
+	sum := sha256.Sum256([]byte(claims.Issuer + " " + claims.Subject))
+
+	return base32.StdEncoding.EncodeToString(sum[:])[:26]
+}
+
+// Username returns the first available of name, preferred username and email.
+func (claims Claims) Username() string {
+	res := claims.Subject
+
+	for _, name := range []string{claims.Email, claims.PreferredUsername, claims.Name} {
+		if name = strings.TrimSpace(name); name != "" {
+			res = name
+		}
+	}
+
+	if runes := []rune(res); len(runes) > maxUsernameSize {
+		res = string(runes[:maxUsernameSize])
+	}
+
+	return res
+}
@@ -0,0 +1,349 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MartyHub/size-it/internal"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	clockSkew     = time.Minute
+	discoveryPath = "/.well-known/openid-configuration"
+)
+
+// Provider implements the authorization code flow, with PKCE, against an OpenID Connect issuer.
+// Its metadata and keys are fetched lazily, so that the issuer does not need to be up when the server starts.
+type Provider struct {
+	mu        sync.Mutex
+	discovery *discovery
+	keys      map[string]crypto.PublicKey
+
+	cfg    internal.Config
+	clk    internal.Clock
+	client *http.Client
+}
+
+type (
+	jwks struct {
+		Keys []jwk `json:"keys"`
+	}
+
+	jwk struct {
+		Kid string `json:"kid"`
+		Kty string `json:"kty"`
+		Crv string `json:"crv"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+		X   string `json:"x"`
+		Y   string `json:"y"`
+	}
+
+	jwtHeader struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+)
+
+func NewProvider(cfg internal.Config, clk internal.Clock, client *http.Client) *Provider {
+	return &Provider{
+		cfg:    cfg,
+		clk:    clk,
+		client: client,
+	}
+}
+
+// AuthCodeURL returns the URL of the issuer to redirect users to.
+func (p *Provider) AuthCodeURL(ctx context.Context, redirectURL string, state authState) (string, error) {
+	dsc, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	challenge := sha256.Sum256([]byte(state.Verifier))
+
+	query := url.Values{
+		"client_id":             {p.cfg.OIDCClientID},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+		"nonce":                 {state.Nonce},
+		"redirect_uri":          {redirectURL},
+		"response_type":         {"code"},
+		"scope":                 {"openid profile email"},
+		"state":                 {state.State},
+	}
+
+	separator := "?"
+	if strings.Contains(dsc.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return dsc.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Exchange trades the authorization code for an ID token, and returns its verified claims.
+func (p *Provider) Exchange(ctx context.Context, redirectURL, code string, state authState) (Claims, error) {
+	dsc, err := p.discover(ctx)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	form := url.Values{
+		"code":          {code},
+		"code_verifier": {state.Verifier},
+		"grant_type":    {"authorization_code"},
+		"redirect_uri":  {redirectURL},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, dsc.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Claims{}, err
+	}
+
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	req.SetBasicAuth(url.QueryEscape(p.cfg.OIDCClientID), url.QueryEscape(p.cfg.OIDCClientSecret))
+
+	var output tokenOutput
+
+	if err = p.do(req, &output); err != nil {
+		return Claims{}, err
+	}
+
+	return p.verify(ctx, output.IDToken, state.Nonce)
+}
+
+// verify checks the signature and the claims of the ID token.
+func (p *Provider) verify(ctx context.Context, idToken, nonce string) (Claims, error) {
+	var claims Claims
+
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 { //nolint:mnd
+		return claims, fmt.Errorf("%w: malformed ID token", internal.ErrUnauthorized)
+	}
+
+	var header jwtHeader
+
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return claims, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return claims, fmt.Errorf("%w: %w", internal.ErrUnauthorized, err)
+	}
+
+	key, err := p.key(ctx, header.Kid)
+	if err != nil {
+		return claims, err
+	}
+
+	if err = verifySignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return claims, err
+	}
+
+	if err = decodeSegment(parts[1], &claims); err != nil {
+		return claims, err
+	}
+
+	return claims, p.checkClaims(claims, nonce)
+}
+
+func (p *Provider) checkClaims(claims Claims, nonce string) error {
+	switch {
+	case strings.TrimSuffix(claims.Issuer, "/") != strings.TrimSuffix(p.cfg.OIDCIssuer, "/"):
+		return fmt.Errorf("%w: unexpected ID token issuer %s", internal.ErrUnauthorized, claims.Issuer)
+	case !slices.Contains(claims.Audience, p.cfg.OIDCClientID):
+		return fmt.Errorf("%w: ID token not issued for this client", internal.ErrUnauthorized)
+	case time.Unix(claims.Expiry, 0).Add(clockSkew).Before(p.clk.Now()):
+		return fmt.Errorf("%w: expired ID token", internal.ErrUnauthorized)
+	case claims.Nonce != nonce:
+		return fmt.Errorf("%w: unexpected ID token nonce", internal.ErrUnauthorized)
+	case claims.Subject == "":
+		return fmt.Errorf("%w: ID token without subject", internal.ErrUnauthorized)
+	}
+
+	return nil
+}
+
+func (p *Provider) discover(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(p.cfg.OIDCIssuer, "/")+discoveryPath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var dsc discovery
+
+	if err = p.do(req, &dsc); err != nil {
+		return nil, err
+	}
+
+	p.discovery = &dsc
+
+	return p.discovery, nil
+}
+
+// key returns the public key with the given ID, fetching keys again when unknown, in case they have been rotated.
+func (p *Provider) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	dsc, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, found := p.keys[kid]; found {
+		return key, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, dsc.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var set jwks
+
+	if err = p.do(req, &set); err != nil {
+		return nil, err
+	}
+
+	p.keys = make(map[string]crypto.PublicKey, len(set.Keys))
+
+	for _, k := range set.Keys {
+		key, err := k.publicKey()
+		if err != nil {
+			internal.LogError("Ignoring OpenID Connect key "+k.Kid, err)
+
+			continue
+		}
+
+		p.keys[k.Kid] = key
+	}
+
+	if key, found := p.keys[kid]; found {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("%w: unknown ID token key %s", internal.ErrUnauthorized, kid)
+}
+
+func (p *Provider) do(req *http.Request, output any) error {
+	req.Header.Set(echo.HeaderAccept, echo.MIMEApplicationJSON)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512)) //nolint:mnd
+
+		return fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Redacted(), resp.Status, body)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(output)
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+	}
+}
+
+func verifySignature(alg string, key crypto.PublicKey, signed string, signature []byte) error {
+	digest := sha256.Sum256([]byte(signed))
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if alg == "RS256" && rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], signature) == nil {
+			return nil
+		}
+	case *ecdsa.PublicKey:
+		const size = 32
+
+		if alg == "ES256" && len(signature) == 2*size {
+			r := new(big.Int).SetBytes(signature[:size])
+			s := new(big.Int).SetBytes(signature[size:])
+
+			if ecdsa.Verify(k, digest[:], r, s) {
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("%w: invalid ID token signature (%s)", internal.ErrUnauthorized, alg)
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("%w: %w", internal.ErrUnauthorized, err)
+	}
+
+	if err = json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %w", internal.ErrUnauthorized, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,337 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MartyHub/size-it/internal"
+)
+
+const (
+	testClientID     = "size-it"
+	testClientSecret = "secret"
+	testKeyID        = "key-1"
+	testRedirectURL  = "http://size-it.local/auth/callback"
+)
+
+type (
+	// mockIssuer implements the endpoints of an OpenID Connect issuer used by the authorization code flow.
+	mockIssuer struct {
+		*httptest.Server
+
+		mu     sync.Mutex
+		key    *rsa.PrivateKey
+		grants map[string]grant
+
+		// claims may be changed by tests before the ID token is signed
+		claims func(claims map[string]any)
+		// signer signs ID tokens instead of key if set
+		signer *rsa.PrivateKey
+	}
+
+	grant struct {
+		challenge string
+		nonce     string
+	}
+)
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048) //nolint:mnd
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := &mockIssuer{
+		key:    key,
+		grants: make(map[string]grant),
+		claims: func(map[string]any) {},
+	}
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET "+discoveryPath, res.discovery)
+	mux.HandleFunc("GET /authorize", res.authorize)
+	mux.HandleFunc("POST /token", res.token)
+	mux.HandleFunc("GET /keys", res.keys)
+
+	res.Server = httptest.NewServer(mux)
+
+	t.Cleanup(res.Close)
+
+	return res
+}
+
+func (iss *mockIssuer) discovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, discovery{
+		Issuer:                iss.URL,
+		AuthorizationEndpoint: iss.URL + "/authorize",
+		TokenEndpoint:         iss.URL + "/token",
+		JWKSURI:               iss.URL + "/keys",
+	})
+}
+
+// authorize signs the user in right away, redirecting to the client with a code.
+func (iss *mockIssuer) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	if query.Get("client_id") != testClientID || query.Get("code_challenge_method") != "S256" {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+
+		return
+	}
+
+	code := query.Get("nonce") + "-code"
+
+	iss.mu.Lock()
+	iss.grants[code] = grant{challenge: query.Get("code_challenge"), nonce: query.Get("nonce")}
+	iss.mu.Unlock()
+
+	redirect := query.Get("redirect_uri") + "?" + url.Values{"code": {code}, "state": {query.Get("state")}}.Encode()
+
+	http.Redirect(w, r, redirect, http.StatusFound)
+}
+
+func (iss *mockIssuer) token(w http.ResponseWriter, r *http.Request) {
+	if id, secret, ok := r.BasicAuth(); !ok || id != testClientID || secret != testClientSecret {
+		http.Error(w, "invalid client", http.StatusUnauthorized)
+
+		return
+	}
+
+	iss.mu.Lock()
+	grt, found := iss.grants[r.PostFormValue("code")]
+	delete(iss.grants, r.PostFormValue("code"))
+	iss.mu.Unlock()
+
+	challenge := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+
+	if !found || grt.challenge != base64.RawURLEncoding.EncodeToString(challenge[:]) {
+		http.Error(w, "invalid grant", http.StatusBadRequest)
+
+		return
+	}
+
+	claims := map[string]any{
+		"iss":   iss.URL,
+		"sub":   "42",
+		"aud":   testClientID,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"nonce": grt.nonce,
+		"name":  "Alice",
+	}
+
+	iss.claims(claims)
+
+	writeJSON(w, tokenOutput{IDToken: iss.sign(claims)})
+}
+
+func (iss *mockIssuer) keys(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, jwks{Keys: []jwk{{
+		Kid: testKeyID,
+		Kty: "RSA",
+		N:   base64.RawURLEncoding.EncodeToString(iss.key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(iss.key.E)).Bytes()),
+	}}})
+}
+
+func (iss *mockIssuer) sign(claims map[string]any) string {
+	header, _ := json.Marshal(jwtHeader{Alg: "RS256", Kid: testKeyID})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	key := iss.key
+	if iss.signer != nil {
+		key = iss.signer
+	}
+
+	signature, _ := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func (iss *mockIssuer) config() internal.Config {
+	return internal.Config{
+		OIDCClientID:     testClientID,
+		OIDCClientSecret: testClientSecret,
+		OIDCIssuer:       iss.URL,
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// noRedirect returns a client which does not follow redirects, like a browser handing them to the test.
+func noRedirect() *http.Client {
+	return &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// authorize follows the authorization URL, and returns the query of the redirect to the client.
+func authorize(t *testing.T, authURL string) url.Values {
+	t.Helper()
+
+	resp, err := noRedirect().Get(authURL) //nolint:noctx
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize: %s", resp.Status)
+	}
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return location.Query()
+}
+
+func testState() authState {
+	return authState{State: "state", Nonce: "nonce", Verifier: "verifier", Next: "/"}
+}
+
+func TestProvider_Exchange(t *testing.T) {
+	iss := newMockIssuer(t)
+	prv := NewProvider(iss.config(), &internal.UTCClock{}, iss.Client())
+	ctx := context.Background()
+	state := testState()
+
+	authURL, err := prv.AuthCodeURL(ctx, testRedirectURL, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := authorize(t, authURL)
+
+	if query.Get("state") != state.State {
+		t.Errorf("state = %q, want %q", query.Get("state"), state.State)
+	}
+
+	claims, err := prv.Exchange(ctx, testRedirectURL, query.Get("code"), state)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if claims.Subject != "42" || claims.Username() != "Alice" || len(claims.UserID()) != 26 {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+}
+
+func TestProvider_Exchange_rejects(t *testing.T) {
+	other, err := rsa.GenerateKey(rand.Reader, 2048) //nolint:mnd
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		setup  func(iss *mockIssuer, state *authState)
+		claims func(claims map[string]any)
+		clk    internal.Clock
+		want   error
+	}{
+		{
+			name: "nonce mismatch",
+			want: internal.ErrUnauthorized,
+			claims: func(claims map[string]any) {
+				claims["nonce"] = "replayed"
+			},
+		},
+		{
+			name: "other issuer",
+			want: internal.ErrUnauthorized,
+			claims: func(claims map[string]any) {
+				claims["iss"] = "https://evil.example"
+			},
+		},
+		{
+			name: "other audience",
+			want: internal.ErrUnauthorized,
+			claims: func(claims map[string]any) {
+				claims["aud"] = []string{"other-client"}
+			},
+		},
+		{
+			name: "expired",
+			want: internal.ErrUnauthorized,
+			clk:  internal.NewFixedClock(time.Now().Add(2 * time.Hour)),
+		},
+		{
+			name: "invalid signature",
+			want: internal.ErrUnauthorized,
+			setup: func(iss *mockIssuer, _ *authState) {
+				iss.signer = other
+			},
+		},
+		{
+			name: "other verifier",
+			setup: func(_ *mockIssuer, state *authState) {
+				state.Verifier = "guessed"
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			iss := newMockIssuer(t)
+			ctx := context.Background()
+			state := testState()
+
+			var clk internal.Clock = &internal.UTCClock{}
+			if tt.clk != nil {
+				clk = tt.clk
+			}
+
+			if tt.claims != nil {
+				iss.claims = tt.claims
+			}
+
+			prv := NewProvider(iss.config(), clk, iss.Client())
+
+			authURL, err := prv.AuthCodeURL(ctx, testRedirectURL, state)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			code := authorize(t, authURL).Get("code")
+
+			if tt.setup != nil {
+				tt.setup(iss, &state)
+			}
+
+			_, err = prv.Exchange(ctx, testRedirectURL, code, state)
+
+			switch {
+			case err == nil:
+				t.Fatal("exchange succeeded")
+			case tt.want != nil && !errors.Is(err, tt.want):
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,10 @@
+package openapi
+
+import (
+	_ "embed"
+)
+
+// Explorer is a self-contained page to browse and try the API described by openapi.json, served alongside.
+//
+//go:embed explorer.html
+var Explorer []byte
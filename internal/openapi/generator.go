@@ -0,0 +1,183 @@
+package openapi
+
+import (
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// generator builds schemas from Go types, following encoding/json rules,
+// named struct types being shared as components.
+type generator struct {
+	names   map[reflect.Type]string
+	schemas map[string]*Schema
+}
+
+func newGenerator() *generator {
+	return &generator{
+		names:   make(map[reflect.Type]string),
+		schemas: make(map[string]*Schema),
+	}
+}
+
+//nolint:cyclop
+func (gen *generator) schema(t reflect.Type) *Schema {
+	if t == reflect.TypeOf(time.Time{}) {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		res := *gen.schema(t.Elem())
+		if res.Ref != "" {
+			// siblings of $ref are ignored
+			return &res
+		}
+
+		res.Nullable = true
+
+		return &res
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+
+		return &Schema{Type: "array", Items: gen.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: gen.schema(t.Elem())}
+	case reflect.Struct:
+		return gen.structSchema(t)
+	default:
+		return &Schema{}
+	}
+}
+
+func (gen *generator) structSchema(t reflect.Type) *Schema {
+	if t.Name() == "" {
+		return gen.object(t)
+	}
+
+	if name, found := gen.names[t]; found {
+		return &Schema{Ref: ref(name)}
+	}
+
+	name := gen.name(t)
+
+	gen.names[t] = name
+	gen.schemas[name] = &Schema{} // placeholder for recursive types
+	gen.schemas[name] = gen.object(t)
+
+	return &Schema{Ref: ref(name)}
+}
+
+func (gen *generator) object(t reflect.Type) *Schema {
+	res := &Schema{
+		Type:       "object",
+		Properties: make(map[string]*Schema),
+	}
+
+	for _, field := range reflect.VisibleFields(t) {
+		name, ok := jsonName(field)
+		if !ok {
+			continue
+		}
+
+		res.Properties[name] = gen.schema(field.Type)
+	}
+
+	return res
+}
+
+// input splits fields of the input type into path or query parameters, and request body.
+func (gen *generator) input(t reflect.Type) ([]Parameter, *RequestBody) {
+	var (
+		params []Parameter
+		body   = &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	)
+
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+
+		if name := field.Tag.Get("param"); name != "" {
+			params = append(params, Parameter{Name: name, In: "path", Required: true, Schema: gen.schema(field.Type)})
+
+			continue
+		}
+
+		if name := field.Tag.Get("query"); name != "" {
+			params = append(params, Parameter{Name: name, In: "query", Schema: gen.schema(field.Type)})
+
+			continue
+		}
+
+		if name, ok := jsonName(field); ok && field.Tag.Get("json") != "" {
+			body.Properties[name] = gen.schema(field.Type)
+		}
+	}
+
+	if len(body.Properties) == 0 {
+		return params, nil
+	}
+
+	return params, &RequestBody{
+		Required: true,
+		Content:  jsonContent(body),
+	}
+}
+
+// name returns the type name, capitalized, prefixed by its package name in case of conflict.
+func (gen *generator) name(t reflect.Type) string {
+	res := capitalize(t.Name())
+
+	if _, found := gen.schemas[res]; found {
+		pkg := t.PkgPath()
+		pkg = pkg[strings.LastIndex(pkg, "/")+1:]
+
+		res = capitalize(pkg) + res
+	}
+
+	return res
+}
+
+func jsonName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() || field.Anonymous {
+		return "", false
+	}
+
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+
+	switch name {
+	case "-":
+		return "", false
+	case "":
+		return field.Name, true
+	default:
+		return name, true
+	}
+}
+
+func capitalize(s string) string {
+	runes := []rune(s)
+	runes[0] = unicode.ToUpper(runes[0])
+
+	return string(runes)
+}
+
+func ref(name string) string {
+	return "#/components/schemas/" + name
+}
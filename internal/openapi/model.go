@@ -0,0 +1,89 @@
+package openapi
+
+const version = "3.0.3"
+
+type (
+	// Document is the subset of an OpenAPI 3 document needed to describe the API.
+	Document struct {
+		OpenAPI    string                          `json:"openapi"`
+		Info       Info                            `json:"info"`
+		Servers    []Server                        `json:"servers,omitempty"`
+		Paths      map[string]map[string]Operation `json:"paths"`
+		Components Components                      `json:"components"`
+	}
+
+	Info struct {
+		Title   string `json:"title"`
+		Version string `json:"version"`
+	}
+
+	Server struct {
+		URL string `json:"url"`
+	}
+
+	Operation struct {
+		OperationID string              `json:"operationId"`
+		Summary     string              `json:"summary,omitempty"`
+		Tags        []string            `json:"tags,omitempty"`
+		Parameters  []Parameter         `json:"parameters,omitempty"`
+		RequestBody *RequestBody        `json:"requestBody,omitempty"`
+		Responses   map[string]Response `json:"responses"`
+		Security    []map[string][]any  `json:"security,omitempty"`
+	}
+
+	Parameter struct {
+		Name     string  `json:"name"`
+		In       string  `json:"in"`
+		Required bool    `json:"required,omitempty"`
+		Schema   *Schema `json:"schema"`
+	}
+
+	RequestBody struct {
+		Required bool                 `json:"required"`
+		Content  map[string]MediaType `json:"content"`
+	}
+
+	Response struct {
+		Description string               `json:"description"`
+		Content     map[string]MediaType `json:"content,omitempty"`
+	}
+
+	MediaType struct {
+		Schema *Schema `json:"schema"`
+	}
+
+	Schema struct {
+		Ref                  string             `json:"$ref,omitempty"`
+		Type                 string             `json:"type,omitempty"`
+		Format               string             `json:"format,omitempty"`
+		Nullable             bool               `json:"nullable,omitempty"`
+		Items                *Schema            `json:"items,omitempty"`
+		Properties           map[string]*Schema `json:"properties,omitempty"`
+		AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	}
+
+	Components struct {
+		Schemas         map[string]*Schema        `json:"schemas"`
+		SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+	}
+
+	SecurityScheme struct {
+		Type   string `json:"type"`
+		Scheme string `json:"scheme,omitempty"`
+		In     string `json:"in,omitempty"`
+		Name   string `json:"name,omitempty"`
+	}
+
+	// Route describes an endpoint with its Go input and output types,
+	// from which parameters, request body and response are generated.
+	Route struct {
+		Summary string
+		Tags    []string
+		// Input fields tagged with param or query are parameters, the ones tagged with json are the request body.
+		Input any
+		// Output is the response body, if any.
+		Output any
+		// Status is the response status, 200 by default.
+		Status int
+	}
+)
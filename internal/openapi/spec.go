@@ -0,0 +1,151 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	errorSchema    = "Error"
+	mimeJSON       = "application/json"
+	securityToken  = "token"
+	securityCookie = "cookie"
+)
+
+// Spec collects routes to generate the OpenAPI document of the API.
+type Spec struct {
+	mu  sync.Mutex
+	doc Document
+	gen *generator
+}
+
+func NewSpec(title, serverURL, cookieName string) *Spec {
+	gen := newGenerator()
+
+	res := &Spec{
+		doc: Document{
+			OpenAPI: version,
+			Info: Info{
+				Title:   title,
+				Version: "unknown",
+			},
+			Paths: make(map[string]map[string]Operation),
+			Components: Components{
+				Schemas: gen.schemas,
+				SecuritySchemes: map[string]SecurityScheme{
+					securityToken:  {Type: "http", Scheme: "bearer"},
+					securityCookie: {Type: "apiKey", In: "cookie", Name: cookieName},
+				},
+			},
+		},
+		gen: gen,
+	}
+
+	if serverURL != "" {
+		res.doc.Servers = []Server{{URL: serverURL}}
+	}
+
+	gen.schemas[errorSchema] = &Schema{
+		Type:       "object",
+		Properties: map[string]*Schema{"message": {Type: "string"}},
+	}
+
+	return res
+}
+
+func (spec *Spec) SetVersion(version string) {
+	spec.mu.Lock()
+	defer spec.mu.Unlock()
+
+	spec.doc.Info.Version = version
+}
+
+// Add describes the route registered with the given method and echo path, like /sessions/:id.
+func (spec *Spec) Add(method, path string, route Route) {
+	spec.mu.Lock()
+	defer spec.mu.Unlock()
+
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	op := Operation{
+		OperationID: operationID(method, path),
+		Summary:     route.Summary,
+		Tags:        route.Tags,
+		Responses: map[string]Response{
+			"default": {
+				Description: "Error",
+				Content:     jsonContent(&Schema{Ref: ref(errorSchema)}),
+			},
+		},
+		Security: []map[string][]any{{securityToken: {}}, {securityCookie: {}}, {}},
+	}
+
+	response := Response{Description: http.StatusText(status)}
+
+	if route.Output != nil {
+		response.Content = jsonContent(spec.gen.schema(reflect.TypeOf(route.Output)))
+	}
+
+	op.Responses[strconv.Itoa(status)] = response
+
+	if route.Input != nil {
+		op.Parameters, op.RequestBody = spec.gen.input(reflect.TypeOf(route.Input))
+	}
+
+	openAPIPath := toOpenAPIPath(path)
+
+	if spec.doc.Paths[openAPIPath] == nil {
+		spec.doc.Paths[openAPIPath] = make(map[string]Operation)
+	}
+
+	spec.doc.Paths[openAPIPath][strings.ToLower(method)] = op
+}
+
+func (spec *Spec) Document() Document {
+	spec.mu.Lock()
+	defer spec.mu.Unlock()
+
+	return spec.doc
+}
+
+func jsonContent(schema *Schema) map[string]MediaType {
+	return map[string]MediaType{mimeJSON: {Schema: schema}}
+}
+
+// toOpenAPIPath converts echo path parameters, like :id, to OpenAPI ones, like {id}.
+func toOpenAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+
+	for i, segment := range segments {
+		if name, found := strings.CutPrefix(segment, ":"); found {
+			segments[i] = "{" + name + "}"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// operationID returns a camel case ID like getApiV1SessionsIdState.
+func operationID(method, path string) string {
+	var sb strings.Builder
+
+	sb.WriteString(strings.ToLower(method))
+
+	for _, segment := range strings.Split(path, "/") {
+		segment = strings.TrimPrefix(segment, ":")
+
+		if segment == "" {
+			continue
+		}
+
+		sb.WriteString(capitalize(segment))
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,58 @@
+package pubsub
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Types of events published while sizing.
+const (
+	SessionCreated = "session.created"
+	TicketSized    = "ticket.sized"
+	VotesRevealed  = "votes.revealed"
+)
+
+type (
+	Event struct {
+		Type      string    `json:"type"`
+		Team      string    `json:"team"`
+		SessionID string    `json:"sessionId"`
+		CreatedAt time.Time `json:"createdAt"`
+		Data      any       `json:"data"`
+	}
+
+	Handler func(ctx context.Context, evt Event)
+
+	// Bus dispatches events to the handlers of this replica, each event being published by a single replica.
+	Bus struct {
+		mu       sync.RWMutex
+		handlers []Handler
+	}
+)
+
+// EventTypes returns the types of events handlers may subscribe to.
+func EventTypes() []string {
+	return []string{SessionCreated, TicketSized, VotesRevealed}
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+func (bus *Bus) Subscribe(hdl Handler) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	bus.handlers = append(bus.handlers, hdl)
+}
+
+// Publish calls every handler synchronously, so they must not block.
+func (bus *Bus) Publish(ctx context.Context, evt Event) {
+	bus.mu.RLock()
+	defer bus.mu.RUnlock()
+
+	for _, hdl := range bus.handlers {
+		hdl(ctx, evt)
+	}
+}
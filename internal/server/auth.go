@@ -2,19 +2,21 @@ package server
 
 import (
 	"context"
-	"encoding/base64"
-	"encoding/json"
 	"errors"
+	"log/slog"
 	"net/http"
+	"strings"
 
 	"github.com/MartyHub/size-it/internal"
 	"github.com/labstack/echo/v4"
 )
 
-func cookieAuth() echo.MiddlewareFunc {
+const bearerPrefix = "Bearer "
+
+func cookieAuth(codec *internal.CookieCodec) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			if err := setUser(c); err != nil {
+			if err := setUser(c, codec); err != nil {
 				return err
 			}
 
@@ -23,7 +25,20 @@ func cookieAuth() echo.MiddlewareFunc {
 	}
 }
 
-func setUser(c echo.Context) error {
+func setUser(c echo.Context, codec *internal.CookieCodec) error {
+	// API clients may send the value of the cookie as a bearer token instead
+	if token, found := strings.CutPrefix(c.Request().Header.Get(echo.HeaderAuthorization), bearerPrefix); found {
+		var usr internal.User
+
+		if err := codec.Open(token, &usr); err != nil {
+			return err
+		}
+
+		authenticate(c, usr)
+
+		return nil
+	}
+
 	cookie, err := c.Cookie(internal.CookieName)
 	if err != nil {
 		if errors.Is(err, http.ErrNoCookie) {
@@ -34,23 +49,34 @@ func setUser(c echo.Context) error {
 	}
 
 	if err = cookie.Valid(); err != nil {
-		return err
-	}
-
-	data, err := base64.RawURLEncoding.DecodeString(cookie.Value)
-	if err != nil {
-		return err
+		return rejectCookie(c, err)
 	}
 
 	var usr internal.User
 
-	if err = json.Unmarshal(data, &usr); err != nil {
-		return err
+	if err = codec.Open(cookie.Value, &usr); err != nil {
+		return rejectCookie(c, err)
 	}
 
+	authenticate(c, usr)
+
+	return nil
+}
+
+func authenticate(c echo.Context, usr internal.User) {
 	ctx := context.WithValue(c.Request().Context(), internal.KeyUser, usr)
 
 	c.SetRequest(c.Request().WithContext(ctx))
+}
+
+// rejectCookie removes an invalid or tampered cookie: the request goes on anonymously.
+func rejectCookie(c echo.Context, err error) error {
+	slog.Warn("Rejecting session cookie",
+		slog.String(internal.LogKeyError, err.Error()),
+		slog.String("ip", c.RealIP()),
+	)
+
+	internal.ClearCookie(c)
 
 	return nil
 }
@@ -0,0 +1,35 @@
+package server
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/MartyHub/size-it/internal/metrics"
+	"github.com/labstack/echo/v4"
+)
+
+func requestMetrics(reg *metrics.Registry) echo.MiddlewareFunc {
+	durations := reg.Histogram(
+		"size_it_http_request_duration_seconds",
+		"Duration of HTTP requests by route and status.",
+		metrics.DefaultBuckets,
+		"method", "route", "status",
+	)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			err := next(c)
+
+			// errors are already handled by the request logger, so the status is final
+			durations.Observe(
+				time.Since(start).Seconds(),
+				c.Request().Method,
+				c.Path(),
+				strconv.Itoa(c.Response().Status),
+			)
+
+			return err
+		}
+	}
+}
@@ -14,6 +14,9 @@ import (
 	"github.com/MartyHub/size-it/internal"
 	"github.com/MartyHub/size-it/internal/db"
 	"github.com/MartyHub/size-it/internal/live"
+	"github.com/MartyHub/size-it/internal/metrics"
+	"github.com/MartyHub/size-it/internal/openapi"
+	"github.com/MartyHub/size-it/internal/pubsub"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 )
@@ -23,16 +26,29 @@ const shutdownTimeout = 10 * time.Second
 type Server struct {
 	Cfg      internal.Config
 	Clk      internal.Clock
+	Cookies  *internal.CookieCodec
 	Event    *live.Service
+	Metrics  *metrics.Registry
+	OpenAPI  *openapi.Spec
+	PubSub   *pubsub.Bus
 	Repo     *db.Repository
 	e        *echo.Echo
 	shutdown chan struct{}
 }
 
-func NewServer(cfg internal.Config, repo *db.Repository) *Server {
+func NewServer(cfg internal.Config, repo *db.Repository) (*Server, error) {
+	cookies, err := internal.NewCookieCodec(cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	res := &Server{
 		Cfg:      cfg,
 		Clk:      &internal.UTCClock{},
+		Cookies:  cookies,
+		Metrics:  metrics.NewRegistry(),
+		OpenAPI:  openapi.NewSpec("SizeIt!", cfg.Path, internal.CookieName),
+		PubSub:   pubsub.NewBus(),
 		Repo:     repo,
 		e:        echo.New(),
 		shutdown: make(chan struct{}),
@@ -40,9 +56,23 @@ func NewServer(cfg internal.Config, repo *db.Repository) *Server {
 
 	res.configure()
 
-	res.Event = live.NewService(res.shutdown, cfg, res.Clk, res.e.Renderer, repo)
+	res.Event = live.NewService(
+		res.shutdown,
+		cfg,
+		res.Clk,
+		res.e.Renderer,
+		repo,
+		res.Metrics,
+		res.PubSub,
+		live.NewRepositoryStore(res.Clk, repo),
+		live.NewRepositoryBus(repo),
+	)
+
+	return res, nil
+}
 
-	return res
+func (srv *Server) DELETE(path string, hdl echo.HandlerFunc) {
+	srv.e.DELETE(path, hdl)
 }
 
 func (srv *Server) GET(path string, hdl echo.HandlerFunc) {
@@ -61,6 +91,12 @@ func (srv *Server) PUT(path string, hdl echo.HandlerFunc) {
 	srv.e.PUT(path, hdl)
 }
 
+// API registers a JSON endpoint, described in the OpenAPI document.
+func (srv *Server) API(method, path string, hdl echo.HandlerFunc, route openapi.Route) {
+	srv.e.Add(method, path, hdl)
+	srv.OpenAPI.Add(method, path, route)
+}
+
 func (srv *Server) Renderer() echo.Renderer { //nolint:ireturn
 	return srv.e.Renderer
 }
@@ -94,10 +130,18 @@ func (srv *Server) configure() {
 		srv.e.Renderer = newEmbedRenderer()
 	}
 
-	srv.e.Use(cookieAuth())
+	srv.e.Use(cookieAuth(srv.Cookies))
+	srv.e.Use(requestMetrics(srv.Metrics))
 	srv.e.Use(requestLogger())
 	srv.e.Use(middleware.Recover())
 
+	srv.e.GET("/api/v1/openapi.json", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, srv.OpenAPI.Document())
+	})
+	srv.e.GET("/api/v1/explorer", func(c echo.Context) error {
+		return c.HTMLBlob(http.StatusOK, openapi.Explorer)
+	})
+
 	srv.e.RouteNotFound("/*", func(c echo.Context) error {
 		return c.Render(http.StatusOK, "notFound.gohtml", nil)
 	})
@@ -0,0 +1,662 @@
+package session
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/MartyHub/size-it/internal"
+	"github.com/MartyHub/size-it/internal/live"
+	"github.com/MartyHub/size-it/internal/openapi"
+	"github.com/MartyHub/size-it/internal/server"
+	"github.com/labstack/echo/v4"
+)
+
+const tagSessions = "sessions"
+
+func (hdl *handler) registerAPI(srv *server.Server) {
+	srv.API(http.MethodPost, "/api/v1/sessions", hdl.apiCreateSession, openapi.Route{
+		Summary: "Create a session, the returned token identifies the user in the next requests",
+		Tags:    []string{tagSessions},
+		Input:   CreateOrJoinSessionInput{},
+		Output:  SessionOutput{},
+		Status:  http.StatusCreated,
+	})
+	srv.API(http.MethodPost, "/api/v1/teams", hdl.apiSelectTeam, openapi.Route{
+		Summary: "Select the team of the user, the returned token identifies the user in the next requests",
+		Tags:    []string{tagSessions},
+		Input:   SelectTeamInput{},
+		Output:  UserOutput{},
+	})
+	srv.API(http.MethodGet, "/api/v1/teams/:team", hdl.apiGetDashboard, openapi.Route{
+		Summary: "Get active, upcoming and recent sessions of the team of the user, " +
+			"with their participants and ticket counts",
+		Tags:   []string{tagSessions},
+		Input:  TeamInput{},
+		Output: TeamDashboard{},
+	})
+	srv.API(http.MethodGet, "/api/v1/teams/:team/sessions", hdl.apiListTeamSessions, openapi.Route{
+		Summary: "List upcoming and recent sessions of the team of the user",
+		Tags:    []string{tagSessions},
+		Input:   TeamInput{},
+		Output:  TeamSessionsOutput{},
+	})
+	srv.API(http.MethodGet, "/api/v1/sessions/:id", hdl.apiGetSession, openapi.Route{
+		Summary: "Get a session of the team of the user",
+		Tags:    []string{tagSessions},
+		Input:   GetSessionInput{},
+		Output:  Session{},
+	})
+	srv.API(http.MethodPost, "/api/v1/sessions/:id/users", hdl.apiJoinSession, openapi.Route{
+		Summary: "Join a session, the returned token identifies the user in the next requests",
+		Tags:    []string{tagSessions},
+		Input:   CreateOrJoinSessionInput{},
+		Output:  SessionOutput{},
+	})
+	srv.API(http.MethodGet, "/api/v1/sessions/:id/state", hdl.apiGetState, openapi.Route{
+		Summary: "Get the live state of a session of the team of the user",
+		Tags:    []string{tagSessions},
+		Input:   GetSessionInput{},
+		Output:  live.View{},
+	})
+	srv.API(http.MethodPut, "/api/v1/sessions/:id/ticket", hdl.apiUpdateTicket, openapi.Route{
+		Summary: "Update the ticket being sized",
+		Tags:    []string{tagSessions},
+		Input:   PatchSessionInput{},
+		Output:  live.View{},
+	})
+	srv.API(http.MethodPut, "/api/v1/sessions/:id/vote", hdl.apiVote, openapi.Route{
+		Summary: "Vote for the ticket being sized, once joined the session",
+		Tags:    []string{tagSessions},
+		Input:   VoteInput{},
+		Output:  live.View{},
+	})
+	srv.API(http.MethodPut, "/api/v1/sessions/:id/timer", hdl.apiStartTimer, openapi.Route{
+		Summary: "Start a timer broadcast to participants, optionally revealing votes once expired, facilitator only",
+		Tags:    []string{tagSessions},
+		Input:   TimerInput{},
+		Output:  live.View{},
+	})
+	srv.API(http.MethodDelete, "/api/v1/sessions/:id/timer", hdl.apiStopTimer, openapi.Route{
+		Summary: "Stop the timer, facilitator only",
+		Tags:    []string{tagSessions},
+		Input:   GetSessionInput{},
+		Output:  live.View{},
+	})
+	srv.API(http.MethodDelete, "/api/v1/sessions/:id/users/:userID", hdl.apiRemoveUser, openapi.Route{
+		Summary: "Disconnect a participant and drop their vote, facilitator only: " +
+			"they can't join the session again for a while",
+		Tags:   []string{tagSessions},
+		Input:  RemoveUserInput{},
+		Output: live.View{},
+	})
+	srv.API(http.MethodPut, "/api/v1/sessions/:id/reveal", hdl.apiReveal, openapi.Route{
+		Summary: "Show or hide votes, facilitator only",
+		Tags:    []string{tagSessions},
+		Input:   RevealInput{},
+		Output:  live.View{},
+	})
+	srv.API(http.MethodPost, "/api/v1/sessions/:id/reset", hdl.apiResetSession, openapi.Route{
+		Summary: "Start sizing a new ticket, facilitator only",
+		Tags:    []string{tagSessions},
+		Input:   GetSessionInput{},
+		Output:  live.View{},
+	})
+	srv.API(http.MethodPost, "/api/v1/sessions/:id/save", hdl.apiSaveTicket, openapi.Route{
+		Summary: "Save the ticket with its votes in history, facilitator only",
+		Tags:    []string{tagSessions},
+		Input:   GetSessionInput{},
+		Output:  live.View{},
+	})
+	srv.API(http.MethodPost, "/api/v1/sessions/:id/backlog", hdl.apiAddToBacklog, openapi.Route{
+		Summary: "Append tickets to the backlog, facilitator only",
+		Tags:    []string{tagSessions},
+		Input:   BacklogInput{},
+		Output:  live.View{},
+	})
+	srv.API(http.MethodPost, "/api/v1/sessions/:id/backlog/import", hdl.apiImportBacklog, openapi.Route{
+		Summary: "Append tickets exported as CSV (summary, url, key) or as JSON by Jira, GitLab or GitHub " +
+			"to the backlog, facilitator only",
+		Tags:   []string{tagSessions},
+		Input:  ImportBacklogInput{},
+		Output: live.View{},
+	})
+	srv.API(http.MethodPut, "/api/v1/sessions/:id/backlog/:itemID", hdl.apiMoveBacklogItem, openapi.Route{
+		Summary: "Move a ticket of the backlog to the given position, starting at 0, facilitator only",
+		Tags:    []string{tagSessions},
+		Input:   MoveBacklogItemInput{},
+		Output:  live.View{},
+	})
+	srv.API(http.MethodDelete, "/api/v1/sessions/:id/backlog/:itemID", hdl.apiRemoveBacklogItem, openapi.Route{
+		Summary: "Remove a ticket from the backlog, facilitator only",
+		Tags:    []string{tagSessions},
+		Input:   BacklogItemParams{},
+		Output:  live.View{},
+	})
+	srv.API(http.MethodPost, "/api/v1/sessions/:id/next", hdl.apiNextTicket, openapi.Route{
+		Summary: "Save the ticket if sized, then start sizing the first ticket of the backlog, facilitator only",
+		Tags:    []string{tagSessions},
+		Input:   GetSessionInput{},
+		Output:  live.View{},
+	})
+	srv.API(http.MethodGet, "/api/v1/sessions/:id/summary", hdl.apiGetSummary, openapi.Route{
+		Summary: "Get a session of the team of the user with every ticket sized during it, and their votes",
+		Tags:    []string{tagSessions},
+		Input:   GetSessionInput{},
+		Output:  SummaryOutput{},
+	})
+	srv.API(http.MethodPost, "/api/v1/sessions/:id/close", hdl.apiCloseSession, openapi.Route{
+		Summary: "Close a session, disconnecting its users: it can't be changed anymore, facilitator only",
+		Tags:    []string{tagSessions},
+		Input:   GetSessionInput{},
+		Output:  Session{},
+	})
+	srv.API(http.MethodPost, "/api/v1/sessions/:id/archive", hdl.apiArchiveSession, openapi.Route{
+		Summary: "Archive a closed session, facilitator only",
+		Tags:    []string{tagSessions},
+		Input:   GetSessionInput{},
+		Output:  Session{},
+	})
+	srv.API(http.MethodGet, "/api/v1/sessions/:id/tickets", hdl.apiListTickets, openapi.Route{
+		Summary: "List tickets saved during a session of the team of the user, with their votes",
+		Tags:    []string{tagSessions},
+		Input:   GetSessionInput{},
+		Output:  []Ticket{},
+	})
+}
+
+func (hdl *handler) apiCreateSession(c echo.Context) error {
+	input, err := internal.Bind[CreateOrJoinSessionInput](c)
+	if err != nil {
+		return err
+	}
+
+	return hdl.apiCreateOrJoin(c, input, http.StatusCreated)
+}
+
+func (hdl *handler) apiJoinSession(c echo.Context) error {
+	input, err := internal.Bind[CreateOrJoinSessionInput](c)
+	if err != nil {
+		return err
+	}
+
+	return hdl.apiCreateOrJoin(c, input, http.StatusOK)
+}
+
+func (hdl *handler) apiCreateOrJoin(c echo.Context, input CreateOrJoinSessionInput, status int) error {
+	session, usr, err := hdl.createOrJoin(c, input)
+	if err != nil {
+		return err
+	}
+
+	// without events, API users stay active as long as they keep using the session
+	if err = hdl.event.Attend(c.Request().Context(), session.ID, usr); err != nil {
+		return err
+	}
+
+	token, err := hdl.cookies.Seal(usr)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(status, SessionOutput{
+		Session: session,
+		User:    usr,
+		Token:   token,
+	})
+}
+
+func (hdl *handler) apiSelectTeam(c echo.Context) error {
+	input, err := internal.Bind[SelectTeamInput](c)
+	if err != nil {
+		return err
+	}
+
+	usr, err := hdl.joinTeam(c, input)
+	if err != nil {
+		return err
+	}
+
+	token, err := hdl.cookies.Seal(usr)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, UserOutput{
+		User:  usr,
+		Token: token,
+	})
+}
+
+func (hdl *handler) apiListTeamSessions(c echo.Context) error {
+	input, err := internal.Bind[TeamInput](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	if _, err = teamMember(ctx, input.Team); err != nil {
+		return err
+	}
+
+	sessions, err := hdl.svc.teamSessions(ctx, input.Team)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, sessions)
+}
+
+func (hdl *handler) apiGetSession(c echo.Context) error {
+	input, err := internal.Bind[GetSessionInput](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	session, err := hdl.svc.get(ctx, input.ID)
+	if err != nil {
+		return err
+	}
+
+	if _, err = teamMember(ctx, session.Team); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, session)
+}
+
+func (hdl *handler) apiGetState(c echo.Context) error {
+	input, err := internal.Bind[GetSessionInput](c)
+	if err != nil {
+		return err
+	}
+
+	return hdl.apiState(c, input.ID)
+}
+
+func (hdl *handler) apiUpdateTicket(c echo.Context) error {
+	input, err := internal.Bind[PatchSessionInput](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = hdl.event.UpdateTicket(ctx, input.SessionID, input.Summary, input.URL, usr); err != nil {
+		return err
+	}
+
+	return hdl.apiState(c, input.SessionID)
+}
+
+func (hdl *handler) apiStartTimer(c echo.Context) error {
+	input, err := internal.Bind[TimerInput](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	duration := time.Duration(input.Duration) * time.Second
+
+	if err = hdl.event.StartTimer(ctx, input.SessionID, duration, input.Reveal, usr); err != nil {
+		return err
+	}
+
+	return hdl.apiState(c, input.SessionID)
+}
+
+func (hdl *handler) apiStopTimer(c echo.Context) error {
+	input, err := internal.Bind[GetSessionInput](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = hdl.event.StopTimer(ctx, input.ID, usr); err != nil {
+		return err
+	}
+
+	return hdl.apiState(c, input.ID)
+}
+
+func (hdl *handler) apiRemoveUser(c echo.Context) error {
+	input, err := internal.Bind[RemoveUserInput](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = hdl.event.RemoveUser(ctx, input.SessionID, input.UserID, usr); err != nil {
+		return err
+	}
+
+	return hdl.apiState(c, input.SessionID)
+}
+
+func (hdl *handler) apiVote(c echo.Context) error {
+	input, err := internal.Bind[VoteInput](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = hdl.event.Attend(ctx, input.SessionID, usr); err != nil {
+		return err
+	}
+
+	if err = hdl.event.SetSizingValue(ctx, input.SessionID, input.DeckID, input.Value, usr); err != nil {
+		return err
+	}
+
+	return hdl.apiState(c, input.SessionID)
+}
+
+func (hdl *handler) apiReveal(c echo.Context) error {
+	input, err := internal.Bind[RevealInput](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = hdl.event.Reveal(ctx, input.SessionID, input.Show, usr); err != nil {
+		return err
+	}
+
+	return hdl.apiState(c, input.SessionID)
+}
+
+func (hdl *handler) apiResetSession(c echo.Context) error {
+	input, err := internal.Bind[GetSessionInput](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = hdl.event.ResetSession(ctx, input.ID, usr); err != nil {
+		return err
+	}
+
+	return hdl.apiState(c, input.ID)
+}
+
+func (hdl *handler) apiSaveTicket(c echo.Context) error {
+	input, err := internal.Bind[GetSessionInput](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = hdl.event.AddTicketToHistory(ctx, input.ID, usr); err != nil {
+		return err
+	}
+
+	return hdl.apiState(c, input.ID)
+}
+
+func (hdl *handler) apiAddToBacklog(c echo.Context) error {
+	input, err := internal.Bind[BacklogInput](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = hdl.event.AddToBacklog(ctx, input.SessionID, toBacklogItems(input.Items), usr); err != nil {
+		return err
+	}
+
+	return hdl.apiState(c, input.SessionID)
+}
+
+// apiImportBacklog identifies the user before reading tickets to import, which may be large.
+func (hdl *handler) apiImportBacklog(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	input, err := internal.Bind[ImportBacklogInput](c)
+	if err != nil {
+		return err
+	}
+
+	items, err := importBacklog(input.Format, []byte(input.Content))
+	if err != nil {
+		return err
+	}
+
+	if err = hdl.event.AddToBacklog(ctx, input.SessionID, toBacklogItems(items), usr); err != nil {
+		return err
+	}
+
+	return hdl.apiState(c, input.SessionID)
+}
+
+func (hdl *handler) apiMoveBacklogItem(c echo.Context) error {
+	input, err := internal.Bind[MoveBacklogItemInput](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = hdl.event.MoveBacklogItem(ctx, input.SessionID, input.ItemID, input.Position, usr); err != nil {
+		return err
+	}
+
+	return hdl.apiState(c, input.SessionID)
+}
+
+func (hdl *handler) apiRemoveBacklogItem(c echo.Context) error {
+	input, err := internal.Bind[BacklogItemParams](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = hdl.event.RemoveBacklogItem(ctx, input.SessionID, input.ItemID, usr); err != nil {
+		return err
+	}
+
+	return hdl.apiState(c, input.SessionID)
+}
+
+func (hdl *handler) apiNextTicket(c echo.Context) error {
+	input, err := internal.Bind[GetSessionInput](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = hdl.event.NextTicket(ctx, input.ID, usr); err != nil {
+		return err
+	}
+
+	return hdl.apiState(c, input.ID)
+}
+
+func (hdl *handler) apiListTickets(c echo.Context) error {
+	input, err := internal.Bind[GetSessionInput](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	session, err := hdl.svc.get(ctx, input.ID)
+	if err != nil {
+		return err
+	}
+
+	if _, err = teamMember(ctx, session.Team); err != nil {
+		return err
+	}
+
+	tickets, err := hdl.svc.tickets(ctx, input.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, tickets)
+}
+
+func (hdl *handler) apiGetSummary(c echo.Context) error {
+	input, err := internal.Bind[GetSessionInput](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	summary, err := hdl.svc.summary(ctx, input.ID)
+	if err != nil {
+		return err
+	}
+
+	if _, err = teamMember(ctx, summary.Session.Team); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, summary)
+}
+
+func (hdl *handler) apiCloseSession(c echo.Context) error {
+	input, err := internal.Bind[GetSessionInput](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = hdl.event.Close(ctx, input.ID, usr); err != nil {
+		return err
+	}
+
+	session, err := hdl.svc.get(ctx, input.ID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, session)
+}
+
+func (hdl *handler) apiArchiveSession(c echo.Context) error {
+	input, err := internal.Bind[GetSessionInput](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	session, err := hdl.svc.archive(ctx, input.ID, usr)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, session)
+}
+
+// apiState returns the live state of the session as seen by the current user, who must be member of its team.
+func (hdl *handler) apiState(c echo.Context, sessionID string) error {
+	ctx := c.Request().Context()
+
+	session, err := hdl.svc.get(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	usr, err := teamMember(ctx, session.Team)
+	if err != nil {
+		return err
+	}
+
+	view, err := hdl.event.View(ctx, sessionID, usr)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, view)
+}
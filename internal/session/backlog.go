@@ -0,0 +1,181 @@
+package session
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/MartyHub/size-it/internal"
+	"github.com/MartyHub/size-it/internal/live"
+	"github.com/labstack/echo/v4"
+)
+
+func (hdl *handler) addToBacklog(c echo.Context) error {
+	input, err := internal.Bind[PasteBacklogInput](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = hdl.event.AddToBacklog(ctx, input.SessionID, toBacklogItems(parseBacklog(input.Items)), usr); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// importBacklog appends the tickets of the uploaded file to the backlog, identifying the user before reading it.
+func (hdl *handler) importBacklog(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	input, err := internal.Bind[ImportBacklogInput](c)
+	if err != nil {
+		return err
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return fmt.Errorf("%w: file to import is required", internal.ErrInvalidInput)
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+
+	defer src.Close()
+
+	data, err := readImport(src)
+	if err != nil {
+		return err
+	}
+
+	items, err := importBacklog(input.Format, data)
+	if err != nil {
+		return err
+	}
+
+	if err = hdl.event.AddToBacklog(ctx, input.SessionID, toBacklogItems(items), usr); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (hdl *handler) moveBacklogItem(c echo.Context) error {
+	input, err := internal.Bind[MoveBacklogItemInput](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = hdl.event.MoveBacklogItem(ctx, input.SessionID, input.ItemID, input.Position, usr); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (hdl *handler) removeBacklogItem(c echo.Context) error {
+	input, err := internal.Bind[BacklogItemParams](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = hdl.event.RemoveBacklogItem(ctx, input.SessionID, input.ItemID, usr); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (hdl *handler) nextTicket(c echo.Context) error {
+	input, err := internal.Bind[GetSessionInput](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = hdl.event.NextTicket(ctx, input.ID, usr); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// parseBacklog reads a ticket per non-blank line: words starting with http:// or https:// make its URL,
+// the other ones its summary, which defaults to the URL.
+func parseBacklog(s string) []BacklogItemInput {
+	var res []BacklogItemInput
+
+	for _, line := range strings.Split(s, "\n") {
+		var (
+			item    BacklogItemInput
+			summary []string
+		)
+
+		for _, word := range strings.Fields(line) {
+			if strings.HasPrefix(word, "http://") || strings.HasPrefix(word, "https://") {
+				item.URL = word
+			} else {
+				summary = append(summary, word)
+			}
+		}
+
+		item.Summary = strings.Join(summary, " ")
+
+		if item.Summary == "" {
+			item.Summary = item.URL
+		}
+
+		if item.Summary != "" {
+			res = append(res, item)
+		}
+	}
+
+	return res
+}
+
+func toBacklogItems(inputs []BacklogItemInput) []live.BacklogItem {
+	res := make([]live.BacklogItem, len(inputs))
+
+	for i, input := range inputs {
+		res[i] = live.BacklogItem{
+			Key:     strings.TrimSpace(input.Key),
+			Summary: strings.TrimSpace(input.Summary),
+			URL:     strings.TrimSpace(input.URL),
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,121 @@
+package session
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/MartyHub/size-it/internal"
+	"github.com/labstack/echo/v4"
+)
+
+func (hdl *handler) getDashboard(c echo.Context) error {
+	input, err := internal.Bind[TeamInput](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := teamMember(ctx, input.Team)
+	if err != nil {
+		return err
+	}
+
+	dashboard, err := hdl.dashboard(ctx, input.Team)
+	if err != nil {
+		return err
+	}
+
+	tickets := make(map[string]int)
+
+	for _, sessions := range [][]DashboardSession{dashboard.Upcoming, dashboard.Recent} {
+		for _, session := range sessions {
+			tickets[session.ID] = session.Tickets
+		}
+	}
+
+	return c.Render(http.StatusOK, "dashboard.gohtml", map[string]any{
+		"path":     hdl.path,
+		"sessions": dashboard,
+		"team":     dashboard.Team,
+		"tickets":  tickets,
+		"user":     usr,
+	})
+}
+
+func (hdl *handler) apiGetDashboard(c echo.Context) error {
+	input, err := internal.Bind[TeamInput](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	if _, err = teamMember(ctx, input.Team); err != nil {
+		return err
+	}
+
+	dashboard, err := hdl.dashboard(ctx, input.Team)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, dashboard)
+}
+
+// dashboard completes the sessions of the team with their ticket counts, and their participants from live states:
+// open sessions with participants are active, whether scheduled later or not.
+func (hdl *handler) dashboard(ctx context.Context, team string) (TeamDashboard, error) {
+	sessions, err := hdl.svc.teamSessions(ctx, team)
+	if err != nil {
+		return TeamDashboard{}, err
+	}
+
+	all := append(sessions.Upcoming, sessions.Recent...) //nolint:gocritic
+	ids := make([]string, len(all))
+	openIDs := make([]string, 0, len(all))
+
+	for i, session := range all {
+		ids[i] = session.ID
+
+		if session.Open() {
+			openIDs = append(openIDs, session.ID)
+		}
+	}
+
+	tickets, err := hdl.svc.ticketCounts(ctx, ids)
+	if err != nil {
+		return TeamDashboard{}, err
+	}
+
+	participants, err := hdl.event.Participants(ctx, openIDs)
+	if err != nil {
+		return TeamDashboard{}, err
+	}
+
+	res := TeamDashboard{
+		Team:     team,
+		Active:   []DashboardSession{},
+		Upcoming: []DashboardSession{},
+		Recent:   []DashboardSession{},
+	}
+
+	for i, session := range all {
+		item := DashboardSession{
+			Session:      session,
+			Participants: participants[session.ID],
+			Tickets:      tickets[session.ID],
+		}
+
+		switch {
+		case item.Participants > 0:
+			res.Active = append(res.Active, item)
+		case i < len(sessions.Upcoming):
+			res.Upcoming = append(res.Upcoming, item)
+		default:
+			res.Recent = append(res.Recent, item)
+		}
+	}
+
+	return res, nil
+}
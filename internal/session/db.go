@@ -1,13 +1,61 @@
 package session
 
 import (
+	"time"
+
 	"github.com/MartyHub/size-it/internal/db/sqlc"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 func toSession(entity sqlc.Session) Session {
 	return Session{
-		ID:        entity.ID,
-		Team:      entity.Team,
+		ID:            entity.ID,
+		Team:          entity.Team,
+		FacilitatorID: entity.FacilitatorID,
+		CreatedAt:     entity.CreatedAt.Time,
+
+		AutoReveal:      entity.AutoReveal,
+		AutoRevealDelay: int(entity.AutoRevealDelay),
+
+		Status:   entity.Status,
+		ClosedAt: toTime(entity.ClosedAt),
+
+		Name:        entity.Name,
+		Description: entity.Description,
+		Sprint:      entity.Sprint,
+		ScheduledAt: toTime(entity.ScheduledAt),
+	}
+}
+
+func toTime(ts pgtype.Timestamp) *time.Time {
+	if !ts.Valid {
+		return nil
+	}
+
+	return &ts.Time
+}
+
+func toTicket(entity sqlc.Ticket, votes []Vote) Ticket {
+	if votes == nil {
+		votes = []Vote{}
+	}
+
+	return Ticket{
+		ID:          entity.ID,
+		Summary:     entity.Summary,
+		URL:         entity.Url,
+		DeckID:      entity.DeckID,
+		SizingValue: entity.SizingValue,
+		Votes:       votes,
+	}
+}
+
+func toVote(entity sqlc.Vote) Vote {
+	return Vote{
+		Round:     int(entity.Round),
+		UserID:    entity.UserID,
+		UserName:  entity.UserName,
+		Value:     entity.Value,
 		CreatedAt: entity.CreatedAt.Time,
 	}
 }
@@ -0,0 +1,118 @@
+package session
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+
+	"github.com/MartyHub/size-it/internal"
+	"github.com/MartyHub/size-it/internal/deck"
+	"github.com/labstack/echo/v4"
+)
+
+func (hdl *handler) listDecks(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetTeamUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	decks, err := hdl.decks.List(ctx, usr.Team)
+	if err != nil {
+		return err
+	}
+
+	return c.Render(http.StatusOK, "decks.gohtml", map[string]any{
+		"decks": decks,
+		"path":  hdl.path,
+		"user":  usr,
+	})
+}
+
+func (hdl *handler) createDeck(c echo.Context) error {
+	input, err := internal.Bind[DeckInput](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetTeamUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	cards, err := deck.ParseCards(input.Cards)
+	if err != nil {
+		return fmt.Errorf("%w: %s", internal.ErrInvalidInput, err.Error())
+	}
+
+	if _, err = hdl.decks.Create(ctx, usr.Team, input.Name, cards); err != nil {
+		return err
+	}
+
+	if err = hdl.event.ReloadDecks(ctx, usr.Team); err != nil {
+		return err
+	}
+
+	return c.Redirect(http.StatusFound, path.Join(hdl.path, "decks"))
+}
+
+func (hdl *handler) getDeck(c echo.Context) error {
+	input, err := internal.Bind[GetDeckInput](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetTeamUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	dck, err := hdl.decks.Get(ctx, input.ID)
+	if err != nil {
+		return err
+	}
+
+	if !dck.Shared() && dck.Team != usr.Team {
+		return fmt.Errorf("%w: deck %d", internal.ErrNotFound, input.ID)
+	}
+
+	return c.Render(http.StatusOK, "deck.gohtml", map[string]any{
+		"deck": dck,
+		"path": hdl.path,
+		"user": usr,
+	})
+}
+
+func (hdl *handler) updateDeck(c echo.Context) error {
+	input, err := internal.Bind[DeckInput](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetTeamUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	cards, err := deck.ParseCards(input.Cards)
+	if err != nil {
+		return fmt.Errorf("%w: %s", internal.ErrInvalidInput, err.Error())
+	}
+
+	if _, err = hdl.decks.Update(ctx, input.ID, usr.Team, input.Name, cards); err != nil {
+		return err
+	}
+
+	if err = hdl.event.ReloadDecks(ctx, usr.Team); err != nil {
+		return err
+	}
+
+	return c.Redirect(http.StatusFound, path.Join(hdl.path, "decks"))
+}
@@ -1,51 +1,103 @@
 package session
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"path"
+	"time"
 
 	"github.com/MartyHub/size-it/internal"
 	"github.com/MartyHub/size-it/internal/db"
+	"github.com/MartyHub/size-it/internal/deck"
 	"github.com/MartyHub/size-it/internal/live"
+	"github.com/MartyHub/size-it/internal/metrics"
 	"github.com/MartyHub/size-it/internal/server"
 	"github.com/labstack/echo/v4"
 )
 
-const sseBufferSize = 8
+const sseBufferSize = 32
 
 func Register(srv *server.Server) {
 	hdl := &handler{
-		path:  srv.Cfg.Path,
-		rdr:   srv.Renderer(),
-		done:  srv.Done(),
-		svc:   newService(srv.Clk, srv.Repo),
-		event: srv.Event,
+		path:      srv.Cfg.Path,
+		rdr:       srv.Renderer(),
+		cookies:   srv.Cookies,
+		oidc:      srv.Cfg.OIDCEnabled(),
+		done:      srv.Done(),
+		svc:       newService(srv.Clk, srv.PubSub, srv.Repo),
+		decks:     deck.NewService(srv.Clk, srv.Repo),
+		event:     srv.Event,
+		heartbeat: srv.Cfg.Heartbeat,
+		sseRetry:  srv.Cfg.SSERetry,
+		sseConnections: srv.Metrics.Gauge(
+			"size_it_sse_connections",
+			"Number of open SSE connections to live sessions.",
+		),
+		wsConnections: srv.Metrics.Gauge(
+			"size_it_ws_connections",
+			"Number of open WebSocket connections to live sessions.",
+		),
 	}
 
 	srv.GET("/", hdl.root)
 
 	srv.POST("/sessions", hdl.createOrJoinSession)
+	srv.POST("/teams", hdl.selectTeam)
+	srv.GET("/teams/:team", hdl.getDashboard)
+	srv.GET("/teams/:team/sessions", hdl.listTeamSessions)
 
 	srv.GET("/sessions/:id", hdl.getSession)
 	srv.PATCH("/sessions/:id", hdl.updateTicket)
 	srv.POST("/sessions/:id", hdl.addTicketToHistory)
 	srv.PUT("/sessions/:id", hdl.resetSession)
 
+	srv.POST("/sessions/:id/rounds", hdl.revote)
+	srv.POST("/sessions/:id/next", hdl.nextTicket)
+	srv.POST("/sessions/:id/backlog", hdl.addToBacklog)
+	srv.POST("/sessions/:id/backlog/import", hdl.importBacklog)
+	srv.PATCH("/sessions/:id/backlog/:itemID", hdl.moveBacklogItem)
+	srv.DELETE("/sessions/:id/backlog/:itemID", hdl.removeBacklogItem)
 	srv.PATCH("/sessions/:id/toggle", hdl.toggleSizings)
-	srv.PATCH("/sessions/:id/:sizingType", hdl.switchSizingType)
-	srv.PATCH("/sessions/:id/:sizingType/:sizingValue", hdl.setSizingValue)
+	srv.PATCH("/sessions/:id/facilitator/:userID", hdl.setFacilitator)
+	srv.DELETE("/sessions/:id/users/:userID", hdl.removeUser)
+	srv.PATCH("/sessions/:id/autoReveal", hdl.setAutoReveal)
+	srv.POST("/sessions/:id/timer", hdl.startTimer)
+	srv.DELETE("/sessions/:id/timer", hdl.stopTimer)
+	srv.PATCH("/sessions/:id/:deckID", hdl.switchDeck)
+	srv.PATCH("/sessions/:id/:deckID/:sizingValue", hdl.setSizingValue)
 	srv.GET("/sessions/:id/user", hdl.updateUser)
+	srv.GET("/sessions/:id/ws", hdl.getSessionWS)
+	srv.GET("/sessions/:id/summary", hdl.getSummary)
+	srv.POST("/sessions/:id/close", hdl.closeSession)
+	srv.POST("/sessions/:id/archive", hdl.archiveSession)
+
+	hdl.registerAPI(srv)
+
+	srv.GET("/decks", hdl.listDecks)
+	srv.POST("/decks", hdl.createDeck)
+	srv.GET("/decks/:deckID", hdl.getDeck)
+	srv.POST("/decks/:deckID", hdl.updateDeck)
 }
 
 const mimeSSE = "text/event-stream"
 
 type handler struct {
-	rdr   echo.Renderer
-	done  <-chan struct{}
-	path  string
-	svc   *service
-	event *live.Service
+	cookies *internal.CookieCodec
+	oidc    bool
+	rdr     echo.Renderer
+	done    <-chan struct{}
+	path    string
+	svc     *service
+	decks   *deck.Service
+	event   *live.Service
+
+	heartbeat      time.Duration
+	sseRetry       time.Duration
+	sseConnections *metrics.Gauge
+	wsConnections  *metrics.Gauge
 }
 
 func (hdl *handler) root(c echo.Context) error {
@@ -61,11 +113,20 @@ func (hdl *handler) root(c echo.Context) error {
 		return err
 	}
 
-	return c.Render(http.StatusOK, "newSession.gohtml", map[string]any{
+	data := map[string]any{
+		"oidc":  hdl.oidc,
 		"path":  hdl.path,
 		"teams": teams,
 		"user":  usr,
-	})
+	}
+
+	if usr.Team != "" {
+		if data["sessions"], err = hdl.svc.teamSessions(ctx, usr.Team); err != nil {
+			return err
+		}
+	}
+
+	return c.Render(http.StatusOK, "newSession.gohtml", data)
 }
 
 func (hdl *handler) createOrJoinSession(c echo.Context) error {
@@ -74,40 +135,139 @@ func (hdl *handler) createOrJoinSession(c echo.Context) error {
 		return err
 	}
 
-	var session Session
+	session, _, err := hdl.createOrJoin(c, input)
+	if err != nil {
+		return err
+	}
+
+	return c.Redirect(http.StatusFound, path.Join(hdl.path, "sessions", session.ID))
+}
+
+func (hdl *handler) listTeamSessions(c echo.Context) error {
+	input, err := internal.Bind[TeamInput](c)
+	if err != nil {
+		return err
+	}
 
 	ctx := c.Request().Context()
 
+	usr, err := teamMember(ctx, input.Team)
+	if err != nil {
+		return err
+	}
+
+	sessions, err := hdl.svc.teamSessions(ctx, input.Team)
+	if err != nil {
+		return err
+	}
+
+	return c.Render(http.StatusOK, "sessions.gohtml", map[string]any{
+		"path":     hdl.path,
+		"sessions": sessions,
+		"team":     input.Team,
+		"user":     usr,
+	})
+}
+
+// teamMember returns the current user, who must have joined a session of the team, or selected it.
+func teamMember(ctx context.Context, team string) (internal.User, error) {
+	usr, err := internal.GetTeamUser(ctx)
+	if err != nil {
+		return usr, err
+	}
+
+	if usr.Team != team {
+		return usr, fmt.Errorf("%w: %s is not member of team %s", internal.ErrUnauthorized, usr.Name, team)
+	}
+
+	return usr, nil
+}
+
+// createOrJoin creates or gets the session, then identifies the user with a cookie.
+func (hdl *handler) createOrJoin(c echo.Context, input CreateOrJoinSessionInput) (Session, internal.User, error) {
+	ctx := c.Request().Context()
+
+	usr, err := hdl.identify(c, input.Username)
+	if err != nil {
+		return Session{}, usr, err
+	}
+
+	var session Session
+
 	if input.ID == "" {
-		session, err = hdl.svc.create(ctx, input)
+		session, err = hdl.svc.create(ctx, input, usr)
 	} else {
 		session, err = hdl.svc.get(ctx, input.ID)
 	}
 
+	if err != nil {
+		return Session{}, usr, err
+	}
+
+	if !session.Open() {
+		return Session{}, usr, fmt.Errorf("%w: session %s is %s", internal.ErrInvalidInput, session.ID, session.Status)
+	}
+
+	usr.Team = session.Team
+
+	if err = internal.SetCookie(c, hdl.cookies, usr); err != nil {
+		return Session{}, usr, err
+	}
+
+	return session, usr, nil
+}
+
+// selectTeam makes the user member of the team, to find its sessions without joining one first.
+func (hdl *handler) selectTeam(c echo.Context) error {
+	input, err := internal.Bind[SelectTeamInput](c)
 	if err != nil {
 		return err
 	}
 
-	usr, err := internal.GetUser(ctx)
+	usr, err := hdl.joinTeam(c, input)
 	if err != nil {
-		if !errors.Is(err, internal.ErrUnauthorized) {
-			return err
+		return err
+	}
+
+	return c.Redirect(http.StatusFound, path.Join(hdl.path, "teams", url.PathEscape(usr.Team)))
+}
+
+// joinTeam identifies the user as member of the team with a cookie.
+func (hdl *handler) joinTeam(c echo.Context, input SelectTeamInput) (internal.User, error) {
+	usr, err := hdl.identify(c, input.Username)
+	if err != nil {
+		return usr, err
+	}
+
+	usr.Team = input.Team
+
+	if err = internal.SetCookie(c, hdl.cookies, usr); err != nil {
+		return usr, err
+	}
+
+	return usr, nil
+}
+
+// identify returns the current user, named after the given username unless signed in with OpenID Connect.
+// Without OpenID Connect, a new user is created if needed.
+func (hdl *handler) identify(c echo.Context, username string) (internal.User, error) {
+	usr, err := internal.GetUser(c.Request().Context())
+	if err != nil {
+		if !errors.Is(err, internal.ErrUnauthorized) || hdl.oidc {
+			return usr, err
 		}
 
 		usr.ID, err = db.NewID()
 		if err != nil {
-			return err
+			return usr, err
 		}
 	}
 
-	usr.Name = input.Username
-	usr.Team = session.Team
-
-	if err = internal.SetCookie(c, usr); err != nil {
-		return err
+	if !hdl.oidc {
+		usr.Name = username
 	}
 
-	return c.Redirect(http.StatusFound, path.Join(hdl.path, "sessions", session.ID))
+	return usr, nil
 }
 
 func (hdl *handler) getSession(c echo.Context) error {
@@ -125,6 +285,14 @@ func (hdl *handler) getSession(c echo.Context) error {
 
 	isSSE := c.Request().Header.Get(echo.HeaderAccept) == mimeSSE
 
+	if !session.Open() {
+		if isSSE {
+			return fmt.Errorf("%w: session %s is %s", internal.ErrInvalidInput, session.ID, session.Status)
+		}
+
+		return c.Redirect(http.StatusFound, path.Join(hdl.path, "sessions", session.ID, "summary"))
+	}
+
 	usr, err := internal.GetUser(ctx)
 	if err != nil {
 		if isSSE {
@@ -133,6 +301,7 @@ func (hdl *handler) getSession(c echo.Context) error {
 
 		if errors.Is(err, internal.ErrUnauthorized) {
 			return c.Render(http.StatusOK, "joinSession.gohtml", map[string]any{
+				"oidc":    hdl.oidc,
 				"path":    hdl.path,
 				"session": session,
 				"user":    usr,
@@ -144,23 +313,24 @@ func (hdl *handler) getSession(c echo.Context) error {
 
 	if isSSE {
 		hdlSSE := &handlerSSE{
-			events:  make(chan live.Event, sseBufferSize),
-			done:    hdl.done,
-			session: session,
-			usr:     usr,
-			svc:     hdl.event,
+			events:      make(chan live.Event, sseBufferSize),
+			done:        hdl.done,
+			heartbeat:   hdl.heartbeat,
+			retry:       hdl.sseRetry,
+			session:     session,
+			usr:         usr,
+			connections: hdl.sseConnections,
+			svc:         hdl.event,
 		}
 
 		return hdlSSE.handle(c)
 	}
 
 	return c.Render(http.StatusOK, "session.gohtml", map[string]any{
-		"path":                   hdl.path,
-		"session":                session,
-		"sessionID":              session.ID,
-		"sizingValueStoryPoints": live.SizingValueStoryPoints,
-		"sizingValueTShirt":      live.SizingValueTShirt,
-		"user":                   usr,
+		"path":      hdl.path,
+		"session":   session,
+		"sessionID": session.ID,
+		"user":      usr,
 	})
 }
 
@@ -177,7 +347,7 @@ func (hdl *handler) updateTicket(c echo.Context) error {
 		return err
 	}
 
-	if err = hdl.event.UpdateTicket(input.SessionID, input.Summary, input.URL, usr); err != nil {
+	if err = hdl.event.UpdateTicket(ctx, input.SessionID, input.Summary, input.URL, usr); err != nil {
 		return err
 	}
 
@@ -210,30 +380,226 @@ func (hdl *handler) toggleSizings(c echo.Context) error {
 		return err
 	}
 
-	if _, err = internal.GetUser(c.Request().Context()); err != nil {
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = hdl.event.ToggleSizings(ctx, input.ID, usr); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (hdl *handler) revote(c echo.Context) error {
+	input, err := internal.Bind[GetSessionInput](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = hdl.event.Revote(ctx, input.ID, usr); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (hdl *handler) setFacilitator(c echo.Context) error {
+	input, err := internal.Bind[PatchFacilitatorInput](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = hdl.event.SetFacilitator(ctx, input.SessionID, input.UserID, usr); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (hdl *handler) setAutoReveal(c echo.Context) error {
+	input, err := internal.Bind[PatchAutoRevealInput](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	delay := time.Duration(input.AutoRevealDelay) * time.Second
+
+	if err = hdl.event.SetAutoReveal(ctx, input.SessionID, input.AutoReveal, delay, usr); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (hdl *handler) getSummary(c echo.Context) error {
+	input, err := internal.Bind[GetSessionInput](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	summary, err := hdl.svc.summary(ctx, input.ID)
+	if err != nil {
 		return err
 	}
 
-	if err = hdl.event.ToggleSizings(input.ID); err != nil {
+	// estimates of a team are only shared with its members
+	usr, err := teamMember(ctx, summary.Session.Team)
+	if err != nil {
+		return err
+	}
+
+	return c.Render(http.StatusOK, "summary.gohtml", map[string]any{
+		"path":    hdl.path,
+		"session": summary.Session,
+		"tickets": summary.Tickets,
+		"user":    usr,
+	})
+}
+
+// closeSession is called by htmx, users are then told by the final event of the session.
+func (hdl *handler) closeSession(c echo.Context) error {
+	input, err := internal.Bind[GetSessionInput](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = hdl.event.Close(ctx, input.ID, usr); err != nil {
 		return err
 	}
 
 	return c.NoContent(http.StatusOK)
 }
 
-func (hdl *handler) switchSizingType(c echo.Context) error {
-	input, err := internal.Bind[PatchSizingTypeInput](c)
+func (hdl *handler) archiveSession(c echo.Context) error {
+	input, err := internal.Bind[GetSessionInput](c)
 	if err != nil {
 		return err
 	}
 
 	ctx := c.Request().Context()
 
-	if _, err = internal.GetUser(ctx); err != nil {
+	usr, err := internal.GetUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err = hdl.svc.archive(ctx, input.ID, usr); err != nil {
+		return err
+	}
+
+	return c.Redirect(http.StatusFound, path.Join(hdl.path, "sessions", input.ID, "summary"))
+}
+
+func (hdl *handler) startTimer(c echo.Context) error {
+	input, err := internal.Bind[TimerInput](c)
+	if err != nil {
 		return err
 	}
 
-	if err = hdl.event.SwitchSizingType(ctx, input.SessionID, input.SizingType); err != nil {
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	duration := time.Duration(input.Duration) * time.Second
+
+	if err = hdl.event.StartTimer(ctx, input.SessionID, duration, input.Reveal, usr); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (hdl *handler) stopTimer(c echo.Context) error {
+	input, err := internal.Bind[GetSessionInput](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = hdl.event.StopTimer(ctx, input.ID, usr); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (hdl *handler) removeUser(c echo.Context) error {
+	input, err := internal.Bind[RemoveUserInput](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = hdl.event.RemoveUser(ctx, input.SessionID, input.UserID, usr); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (hdl *handler) switchDeck(c echo.Context) error {
+	input, err := internal.Bind[PatchDeckInput](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = hdl.event.SwitchDeck(ctx, input.SessionID, input.DeckID, usr); err != nil {
 		return err
 	}
 
@@ -246,12 +612,14 @@ func (hdl *handler) setSizingValue(c echo.Context) error {
 		return err
 	}
 
-	usr, err := internal.GetUser(c.Request().Context())
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetUser(ctx)
 	if err != nil {
 		return err
 	}
 
-	if err = hdl.event.SetSizingValue(input.SessionID, input.SizingValue, usr); err != nil {
+	if err = hdl.event.SetSizingValue(ctx, input.SessionID, input.DeckID, input.SizingValue, usr); err != nil {
 		return err
 	}
 
@@ -264,11 +632,14 @@ func (hdl *handler) resetSession(c echo.Context) error {
 		return err
 	}
 
-	if _, err = internal.GetUser(c.Request().Context()); err != nil {
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetUser(ctx)
+	if err != nil {
 		return err
 	}
 
-	if err = hdl.event.ResetSession(input.ID); err != nil {
+	if err = hdl.event.ResetSession(ctx, input.ID, usr); err != nil {
 		return err
 	}
 
@@ -294,6 +665,7 @@ func (hdl *handler) updateUser(c echo.Context) error {
 	}
 
 	return c.Render(http.StatusOK, "joinSession.gohtml", map[string]any{
+		"oidc":    hdl.oidc,
 		"path":    hdl.path,
 		"session": session,
 		"user":    usr,
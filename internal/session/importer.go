@@ -0,0 +1,314 @@
+package session
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/MartyHub/size-it/internal"
+)
+
+const (
+	formatAuto   = "auto"
+	formatCSV    = "csv"
+	formatGitHub = "github"
+	formatGitLab = "gitlab"
+	formatJira   = "jira"
+
+	maxImportSize = 1 << 20
+)
+
+type (
+	// jiraExport is the result of a Jira search, as exported by its REST API.
+	jiraExport struct {
+		Issues []jiraIssue `json:"issues"`
+	}
+
+	jiraIssue struct {
+		Key    string `json:"key"`
+		Self   string `json:"self"`
+		Fields struct {
+			Summary string `json:"summary"`
+		} `json:"fields"`
+	}
+
+	gitLabIssue struct {
+		IID        int64  `json:"iid"`
+		Title      string `json:"title"`
+		WebURL     string `json:"web_url"`
+		References struct {
+			Full string `json:"full"`
+		} `json:"references"`
+	}
+
+	// gitHubIssue is returned by the REST API, or by the CLI with: gh issue list --json number,title,url.
+	gitHubIssue struct {
+		Number      int64     `json:"number"`
+		Title       string    `json:"title"`
+		HTMLURL     string    `json:"html_url"`
+		URL         string    `json:"url"`
+		PullRequest *struct{} `json:"pull_request"`
+	}
+)
+
+// importBacklog parses tickets exported from a spreadsheet or an issue tracker,
+// reporting every invalid row.
+func importBacklog(format string, data []byte) ([]BacklogItemInput, error) {
+	if format == "" || format == formatAuto {
+		if format = detectFormat(data); format == "" {
+			return nil, fmt.Errorf("%w: format of the tickets to import cannot be detected", internal.ErrInvalidInput)
+		}
+	}
+
+	var (
+		items    []BacklogItemInput
+		firstRow = 1
+		err      error
+	)
+
+	switch format {
+	case formatCSV:
+		items, firstRow, err = parseCSV(data)
+	case formatGitHub:
+		items, err = parseGitHub(data)
+	case formatGitLab:
+		items, err = parseGitLab(data)
+	case formatJira:
+		items, err = parseJira(data)
+	default:
+		return nil, fmt.Errorf("%w: unknown format %s", internal.ErrInvalidInput, format)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", internal.ErrInvalidInput, err.Error())
+	}
+
+	switch {
+	case len(items) == 0:
+		return nil, fmt.Errorf("%w: no ticket to import", internal.ErrInvalidInput)
+	case len(items) > maxBacklogItems:
+		return nil, fmt.Errorf("%w: %d tickets to import, at most %d", internal.ErrInvalidInput, len(items), maxBacklogItems)
+	}
+
+	if err = validateRows(items, firstRow); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// detectFormat guesses the format from the shape of the first issue of JSON exports, CSV otherwise.
+func detectFormat(data []byte) string {
+	var export any
+
+	if err := json.Unmarshal(data, &export); err != nil {
+		return formatCSV
+	}
+
+	if obj, ok := export.(map[string]any); ok {
+		if _, found := obj["issues"]; found {
+			return formatJira
+		}
+
+		return ""
+	}
+
+	issues, _ := export.([]any)
+	if len(issues) == 0 {
+		return ""
+	}
+
+	issue, _ := issues[0].(map[string]any)
+
+	switch {
+	case issue["fields"] != nil:
+		return formatJira
+	case issue["web_url"] != nil || issue["iid"] != nil:
+		return formatGitLab
+	case issue["number"] != nil:
+		return formatGitHub
+	}
+
+	return ""
+}
+
+// parseCSV reads summary, url and key columns, in this order unless named by a header row.
+func parseCSV(data []byte) ([]BacklogItemInput, int, error) {
+	rdr := csv.NewReader(bytes.NewReader(data))
+	rdr.FieldsPerRecord = -1
+	rdr.TrimLeadingSpace = true
+
+	records, err := rdr.ReadAll()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	columns := map[string]int{"summary": 0, "url": 1, "key": 2} //nolint:mnd
+	firstRow := 1
+
+	if len(records) > 0 && isCSVHeader(records[0]) {
+		columns = make(map[string]int)
+
+		for i, name := range records[0] {
+			columns[strings.ToLower(strings.TrimSpace(name))] = i
+		}
+
+		records = records[1:]
+		firstRow++
+	}
+
+	res := make([]BacklogItemInput, 0, len(records))
+
+	for _, record := range records {
+		res = append(res, BacklogItemInput{
+			Key:     csvColumn(record, columns, "key"),
+			Summary: csvColumn(record, columns, "summary"),
+			URL:     csvColumn(record, columns, "url"),
+		})
+	}
+
+	return res, firstRow, nil
+}
+
+func isCSVHeader(record []string) bool {
+	for _, name := range record {
+		if strings.EqualFold(strings.TrimSpace(name), "summary") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func csvColumn(record []string, columns map[string]int, name string) string {
+	i, found := columns[name]
+	if !found || i >= len(record) {
+		return ""
+	}
+
+	return strings.TrimSpace(record[i])
+}
+
+// parseJira reads the result of a search, or a list of issues.
+func parseJira(data []byte) ([]BacklogItemInput, error) {
+	var export jiraExport
+
+	if err := json.Unmarshal(data, &export); err != nil {
+		if err = json.Unmarshal(data, &export.Issues); err != nil {
+			return nil, err
+		}
+	}
+
+	res := make([]BacklogItemInput, len(export.Issues))
+
+	for i, issue := range export.Issues {
+		res[i] = BacklogItemInput{
+			Key:     issue.Key,
+			Summary: issue.Fields.Summary,
+			URL:     jiraBrowseURL(issue),
+		}
+	}
+
+	return res, nil
+}
+
+// jiraBrowseURL returns the web page of the issue, on the site of its REST API URL.
+func jiraBrowseURL(issue jiraIssue) string {
+	u, err := url.Parse(issue.Self)
+	if err != nil || u.Host == "" || issue.Key == "" {
+		return ""
+	}
+
+	return (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/browse/" + issue.Key}).String()
+}
+
+func parseGitLab(data []byte) ([]BacklogItemInput, error) {
+	var issues []gitLabIssue
+
+	if err := json.Unmarshal(data, &issues); err != nil {
+		return nil, err
+	}
+
+	res := make([]BacklogItemInput, len(issues))
+
+	for i, issue := range issues {
+		key := issue.References.Full
+		if key == "" {
+			key = "#" + strconv.FormatInt(issue.IID, 10)
+		}
+
+		res[i] = BacklogItemInput{
+			Key:     key,
+			Summary: issue.Title,
+			URL:     issue.WebURL,
+		}
+	}
+
+	return res, nil
+}
+
+// parseGitHub ignores pull requests, listed along with issues by the REST API.
+func parseGitHub(data []byte) ([]BacklogItemInput, error) {
+	var issues []gitHubIssue
+
+	if err := json.Unmarshal(data, &issues); err != nil {
+		return nil, err
+	}
+
+	res := make([]BacklogItemInput, 0, len(issues))
+
+	for _, issue := range issues {
+		if issue.PullRequest != nil {
+			continue
+		}
+
+		u := issue.HTMLURL
+		if u == "" {
+			u = issue.URL
+		}
+
+		res = append(res, BacklogItemInput{
+			Key:     "#" + strconv.FormatInt(issue.Number, 10),
+			Summary: issue.Title,
+			URL:     u,
+		})
+	}
+
+	return res, nil
+}
+
+func validateRows(items []BacklogItemInput, firstRow int) error {
+	var errs []error
+
+	for i, item := range items {
+		if err := item.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("row %d: %w", firstRow+i, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %s", internal.ErrInvalidInput, errors.Join(errs...).Error())
+	}
+
+	return nil
+}
+
+// readImport reads at most maxImportSize bytes.
+func readImport(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxImportSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) > maxImportSize {
+		return nil, fmt.Errorf("%w: file to import exceeds %d bytes", internal.ErrInvalidInput, maxImportSize)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,168 @@
+package session
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/MartyHub/size-it/internal"
+)
+
+const (
+	testJiraSearch = `{"issues": [
+		{"key": "SIZE-1", "self": "https://jira.example.com/rest/api/2/issue/10001", "fields": {"summary": "Login"}},
+		{"key": "SIZE-2", "self": "https://jira.example.com/rest/api/2/issue/10002", "fields": {"summary": "Logout"}}
+	]}`
+	testGitLabIssues = `[
+		{"iid": 3, "title": "Login", "web_url": "https://gitlab.example.com/acme/app/-/issues/3",
+			"references": {"full": "acme/app#3"}},
+		{"iid": 4, "title": "Logout", "web_url": "https://gitlab.example.com/acme/app/-/issues/4"}
+	]`
+	testGitHubIssues = `[
+		{"number": 5, "title": "Login", "html_url": "https://github.com/acme/app/issues/5"},
+		{"number": 6, "title": "Fix login", "html_url": "https://github.com/acme/app/pull/6", "pull_request": {}},
+		{"number": 7, "title": "Logout", "url": "https://github.com/acme/app/issues/7"}
+	]`
+)
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{name: "csv", data: "Login,https://example.com/1", want: formatCSV},
+		{name: "jira search", data: testJiraSearch, want: formatJira},
+		{name: "jira issues", data: `[{"key": "SIZE-1", "fields": {"summary": "Login"}}]`, want: formatJira},
+		{name: "gitlab", data: testGitLabIssues, want: formatGitLab},
+		{name: "github", data: testGitHubIssues, want: formatGitHub},
+		{name: "unknown object", data: `{"items": []}`, want: ""},
+		{name: "unknown issue", data: `[{"name": "Login"}]`, want: ""},
+		{name: "empty list", data: `[]`, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectFormat([]byte(tt.data)); got != tt.want {
+				t.Errorf("detectFormat = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImportBacklog(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		data   string
+		want   []BacklogItemInput
+	}{
+		{
+			name: "csv without header",
+			data: "Login, https://example.com/1, SIZE-1\nLogout",
+			want: []BacklogItemInput{
+				{Key: "SIZE-1", Summary: "Login", URL: "https://example.com/1"},
+				{Summary: "Logout"},
+			},
+		},
+		{
+			name:   "csv with header",
+			format: formatCSV,
+			data:   "Key,URL,Summary,Priority\nSIZE-1,https://example.com/1,Login,High\n",
+			want:   []BacklogItemInput{{Key: "SIZE-1", Summary: "Login", URL: "https://example.com/1"}},
+		},
+		{
+			name: "jira search",
+			data: testJiraSearch,
+			want: []BacklogItemInput{
+				{Key: "SIZE-1", Summary: "Login", URL: "https://jira.example.com/browse/SIZE-1"},
+				{Key: "SIZE-2", Summary: "Logout", URL: "https://jira.example.com/browse/SIZE-2"},
+			},
+		},
+		{
+			name:   "jira issues",
+			format: formatJira,
+			data:   `[{"key": "SIZE-1", "fields": {"summary": "Login"}}]`,
+			want:   []BacklogItemInput{{Key: "SIZE-1", Summary: "Login"}},
+		},
+		{
+			name: "gitlab",
+			data: testGitLabIssues,
+			want: []BacklogItemInput{
+				{Key: "acme/app#3", Summary: "Login", URL: "https://gitlab.example.com/acme/app/-/issues/3"},
+				{Key: "#4", Summary: "Logout", URL: "https://gitlab.example.com/acme/app/-/issues/4"},
+			},
+		},
+		{
+			name: "github without pull requests",
+			data: testGitHubIssues,
+			want: []BacklogItemInput{
+				{Key: "#5", Summary: "Login", URL: "https://github.com/acme/app/issues/5"},
+				{Key: "#7", Summary: "Logout", URL: "https://github.com/acme/app/issues/7"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := importBacklog(tt.format, []byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("importBacklog = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImportBacklog_invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		data   string
+		want   []string
+	}{
+		{
+			name: "bad csv rows",
+			data: "summary,url\nLogin,https://example.com/1\nLogout,ftp://example.com/2\n,https://example.com/3",
+			want: []string{"row 3: url:", "row 4: summary:"},
+		},
+		{
+			name:   "bad json rows",
+			format: formatGitLab,
+			data:   `[{"iid": 3, "title": "Login"}, {"iid": 4, "web_url": "https://gitlab.example.com/acme/app/-/issues/4"}]`,
+			want:   []string{"row 2: summary:"},
+		},
+		{
+			// without a summary column, the header is taken as a ticket
+			name: "unknown csv header",
+			data: "title,link\nLogin,https://example.com/1",
+			want: []string{"row 1: url:"},
+		},
+		{name: "csv header only", data: "summary,url\n", want: []string{"no ticket to import"}},
+		{name: "malformed csv", data: "summary\n\"Login", want: []string{"extraneous or missing \" in quoted-field"}},
+		{name: "undetected format", data: `{"items": []}`, want: []string{"cannot be detected"}},
+		{name: "unknown format", format: "xml", data: "<issues/>", want: []string{"unknown format xml"}},
+		{name: "not json", format: formatJira, data: "Login", want: []string{"invalid character"}},
+		{name: "empty", data: "", want: []string{"no ticket to import"}},
+		{name: "too many", data: strings.Repeat("Login\n", maxBacklogItems+1), want: []string{"101 tickets to import"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := importBacklog(tt.format, []byte(tt.data))
+			if !errors.Is(err, internal.ErrInvalidInput) {
+				t.Fatalf("importBacklog error = %v, want invalid input", err)
+			}
+
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("importBacklog error = %v, want %q", err, want)
+				}
+			}
+		})
+	}
+}
@@ -1,17 +1,51 @@
 package session
 
 import (
+	"errors"
+	"net/url"
 	"time"
 
-	"github.com/MartyHub/size-it/internal/live"
+	"github.com/MartyHub/size-it/internal"
+	"github.com/MartyHub/size-it/internal/db"
+	"github.com/MartyHub/size-it/internal/deck"
 	"github.com/invopop/validation"
 )
 
+const (
+	maxAutoRevealDelay = 60
+	maxBacklogItems    = 100
+	maxDeckNameSize    = 32
+	maxKeySize         = 64
+	maxTicketFieldSize = 512
+	maxTimerDuration   = 3600
+	maxSessionNameSize = 64
+	maxSprintSize      = 32
+	maxUsernameSize    = 32
+
+	// dateTimeLocal is the layout of HTML datetime-local inputs.
+	dateTimeLocal = "2006-01-02T15:04"
+)
+
 type (
 	CreateOrJoinSessionInput struct {
-		ID       string `form:"id"`
-		Team     string `form:"team"`
-		Username string `form:"username"`
+		ID       string `form:"id"       json:"-"        param:"id"`
+		Team     string `form:"team"     json:"team"`
+		Username string `form:"username" json:"username"`
+
+		AutoReveal      bool `form:"autoReveal"      json:"autoReveal"`
+		AutoRevealDelay int  `form:"autoRevealDelay" json:"autoRevealDelay"`
+
+		Name        string `form:"name"        json:"name"`
+		Description string `form:"description" json:"description"`
+		Sprint      string `form:"sprint"      json:"sprint"`
+		// ScheduledAt is either RFC 3339, or the local date and time of an HTML input, taken as UTC.
+		ScheduledAt string `form:"scheduledAt" json:"scheduledAt"`
+	}
+
+	// SelectTeamInput makes the user member of a team, the username is ignored with OpenID Connect.
+	SelectTeamInput struct {
+		Team     string `form:"team"     json:"team"`
+		Username string `form:"username" json:"username"`
 	}
 
 	GetSessionInput struct {
@@ -21,32 +55,267 @@ type (
 	PatchSessionInput struct {
 		SessionID string `param:"id"`
 
-		Summary string `form:"summary"`
-		URL     string `form:"url"`
+		Summary string `form:"summary" json:"summary"`
+		URL     string `form:"url"     json:"url"`
+	}
+
+	PatchAutoRevealInput struct {
+		SessionID string `param:"id"`
+
+		AutoReveal      bool `form:"autoReveal"`
+		AutoRevealDelay int  `form:"autoRevealDelay"`
 	}
 
-	PatchSizingTypeInput struct {
-		SessionID  string `param:"id"`
-		SizingType string `param:"sizingType"`
+	PatchFacilitatorInput struct {
+		SessionID string `param:"id"`
+		UserID    string `param:"userID"`
+	}
+
+	// TimerInput starts a timer of the given duration in seconds, possibly revealing votes once expired.
+	TimerInput struct {
+		SessionID string `param:"id"`
+
+		Duration int  `form:"duration" json:"duration"`
+		Reveal   bool `form:"reveal"   json:"reveal"`
+	}
+
+	RemoveUserInput struct {
+		SessionID string `param:"id"`
+		UserID    string `param:"userID"`
+	}
+
+	PatchDeckInput struct {
+		SessionID string `param:"id"`
+		DeckID    int64  `param:"deckID"`
 	}
 
 	PatchSizingValueInput struct {
 		SessionID   string `param:"id"`
-		SizingType  string `param:"sizingType"`
+		DeckID      int64  `param:"deckID"`
 		SizingValue string `param:"sizingValue"`
 	}
 
+	VoteInput struct {
+		SessionID string `param:"id"`
+
+		DeckID int64  `json:"deckId"`
+		Value  string `json:"value"`
+	}
+
+	RevealInput struct {
+		SessionID string `param:"id"`
+
+		Show bool `json:"show"`
+	}
+
+	// PasteBacklogInput holds tickets pasted one per line: a summary, optionally followed by a URL.
+	PasteBacklogInput struct {
+		SessionID string `param:"id"`
+		Items     string `form:"items"`
+	}
+
+	BacklogInput struct {
+		SessionID string             `param:"id"`
+		Items     []BacklogItemInput `json:"items"`
+	}
+
+	BacklogItemInput struct {
+		Key     string `json:"key"`
+		Summary string `json:"summary"`
+		URL     string `json:"url"`
+	}
+
+	// ImportBacklogInput holds tickets exported as CSV (summary, url, key),
+	// or as JSON by the REST API of Jira, GitLab or GitHub.
+	ImportBacklogInput struct {
+		SessionID string `param:"id"`
+
+		Format  string `form:"format" json:"format"`
+		Content string `json:"content"`
+	}
+
+	BacklogItemParams struct {
+		SessionID string `param:"id"`
+		ItemID    int64  `param:"itemID"`
+	}
+
+	MoveBacklogItemInput struct {
+		SessionID string `param:"id"`
+		ItemID    int64  `param:"itemID"`
+
+		Position int `form:"position" json:"position"`
+	}
+
+	GetDeckInput struct {
+		ID int64 `param:"deckID"`
+	}
+
+	DeckInput struct {
+		ID    int64  `param:"deckID"`
+		Name  string `form:"name"`
+		Cards string `form:"cards"`
+	}
+
 	Session struct {
-		ID        string    `json:"id"`
-		Team      string    `json:"team"`
+		ID            string    `json:"id"`
+		Team          string    `json:"team"`
+		FacilitatorID string    `json:"facilitatorId"`
+		CreatedAt     time.Time `json:"createdAt"`
+
+		AutoReveal      bool `json:"autoReveal"`
+		AutoRevealDelay int  `json:"autoRevealDelay"`
+
+		Status   string     `json:"status"`
+		ClosedAt *time.Time `json:"closedAt,omitempty"`
+
+		Name        string     `json:"name,omitempty"`
+		Description string     `json:"description,omitempty"`
+		Sprint      string     `json:"sprint,omitempty"`
+		ScheduledAt *time.Time `json:"scheduledAt,omitempty"`
+	}
+
+	// TeamSessionsOutput lists sessions of a team scheduled later, then the recent ones, archived ones excepted.
+	TeamSessionsOutput struct {
+		Upcoming []Session `json:"upcoming"`
+		Recent   []Session `json:"recent"`
+	}
+
+	// TeamDashboard splits sessions of a team between those with connected users, upcoming and recent ones.
+	TeamDashboard struct {
+		Team     string             `json:"team"`
+		Active   []DashboardSession `json:"active"`
+		Upcoming []DashboardSession `json:"upcoming"`
+		Recent   []DashboardSession `json:"recent"`
+	}
+
+	DashboardSession struct {
+		Session
+
+		Participants int `json:"participants"`
+		Tickets      int `json:"tickets"`
+	}
+
+	TeamInput struct {
+		Team string `param:"team"`
+	}
+
+	// SummaryOutput lists every ticket sized during a session, with its votes.
+	SummaryOutput struct {
+		Session Session  `json:"session"`
+		Tickets []Ticket `json:"tickets"`
+	}
+
+	// SessionOutput is returned to API clients creating or joining a session:
+	// the token authenticates the next requests as a bearer token.
+	SessionOutput struct {
+		Session Session       `json:"session"`
+		User    internal.User `json:"user"`
+		Token   string        `json:"token"`
+	}
+
+	// UserOutput identifies the user in the next requests with its token.
+	UserOutput struct {
+		User  internal.User `json:"user"`
+		Token string        `json:"token"`
+	}
+
+	Ticket struct {
+		ID          int64  `json:"id"`
+		Summary     string `json:"summary"`
+		URL         string `json:"url"`
+		DeckID      int64  `json:"deckId"`
+		SizingValue string `json:"sizingValue"`
+		Votes       []Vote `json:"votes"`
+	}
+
+	Vote struct {
+		Round     int       `json:"round"`
+		UserID    string    `json:"userId"`
+		UserName  string    `json:"userName"`
+		Value     string    `json:"value"`
 		CreatedAt time.Time `json:"createdAt"`
 	}
 )
 
+func (session Session) Open() bool {
+	return session.Status == db.SessionOpen
+}
+
+func (session Session) Closed() bool {
+	return session.Status == db.SessionClosed
+}
+
+// Title is the name of the session, or describes it if unnamed.
+func (session Session) Title() string {
+	if session.Name != "" {
+		return session.Name
+	}
+
+	return "Sizing session for team " + session.Team
+}
+
 func (input CreateOrJoinSessionInput) Validate() error {
 	return validation.ValidateStruct(&input,
-		validation.Field(&input.Username, validation.Required),
+		validation.Field(&input.Username, validation.Required, validation.RuneLength(1, maxUsernameSize)),
 		validation.Field(&input.Team, validation.When(input.ID == "", validation.Required)),
+		validation.Field(&input.AutoRevealDelay, validation.Min(0), validation.Max(maxAutoRevealDelay)),
+		validation.Field(&input.Name, validation.Length(0, maxSessionNameSize)),
+		validation.Field(&input.Description, validation.Length(0, maxTicketFieldSize)),
+		validation.Field(&input.Sprint, validation.Length(0, maxSprintSize)),
+		validation.Field(&input.ScheduledAt, validation.By(func(any) error {
+			_, err := input.scheduledAt()
+
+			return err
+		})),
+	)
+}
+
+// scheduledAt returns the zero time if the session is not scheduled.
+func (input CreateOrJoinSessionInput) scheduledAt() (time.Time, error) {
+	if input.ScheduledAt == "" {
+		return time.Time{}, nil
+	}
+
+	for _, layout := range []string{time.RFC3339, dateTimeLocal} {
+		if res, err := time.Parse(layout, input.ScheduledAt); err == nil {
+			return res.UTC(), nil
+		}
+	}
+
+	return time.Time{}, errors.New("must be a date and time")
+}
+
+func (input SelectTeamInput) Validate() error {
+	return validation.ValidateStruct(&input,
+		validation.Field(&input.Username, validation.Required, validation.RuneLength(1, maxUsernameSize)),
+		validation.Field(&input.Team, validation.Required),
+	)
+}
+
+func (input TeamInput) Validate() error {
+	return validation.ValidateStruct(&input,
+		validation.Field(&input.Team, validation.Required),
+	)
+}
+
+func (input VoteInput) Validate() error {
+	return validation.ValidateStruct(&input,
+		validation.Field(&input.SessionID, validation.Required),
+		validation.Field(&input.DeckID, validation.Required),
+		validation.Field(&input.Value, validation.Required),
+	)
+}
+
+func (input RevealInput) Validate() error {
+	return validation.ValidateStruct(&input,
+		validation.Field(&input.SessionID, validation.Required),
+	)
+}
+
+func (input PatchAutoRevealInput) Validate() error {
+	return validation.ValidateStruct(&input,
+		validation.Field(&input.SessionID, validation.Required),
+		validation.Field(&input.AutoRevealDelay, validation.Min(0), validation.Max(maxAutoRevealDelay)),
 	)
 }
 
@@ -62,23 +331,121 @@ func (input PatchSessionInput) Validate() error {
 	)
 }
 
-func (input PatchSizingTypeInput) Validate() error {
+func (input PatchFacilitatorInput) Validate() error {
+	return validation.ValidateStruct(&input,
+		validation.Field(&input.SessionID, validation.Required),
+		validation.Field(&input.UserID, validation.Required),
+	)
+}
+
+func (input TimerInput) Validate() error {
+	return validation.ValidateStruct(&input,
+		validation.Field(&input.SessionID, validation.Required),
+		validation.Field(&input.Duration, validation.Required, validation.Min(1), validation.Max(maxTimerDuration)),
+	)
+}
+
+func (input RemoveUserInput) Validate() error {
 	return validation.ValidateStruct(&input,
 		validation.Field(&input.SessionID, validation.Required),
-		validation.Field(&input.SizingType, validation.In(
-			live.SizingTypeStoryPoints,
-			live.SizingTypeTShirt,
-		)),
+		validation.Field(&input.UserID, validation.Required),
+	)
+}
+
+func (input PatchDeckInput) Validate() error {
+	return validation.ValidateStruct(&input,
+		validation.Field(&input.SessionID, validation.Required),
+		validation.Field(&input.DeckID, validation.Required),
 	)
 }
 
 func (input PatchSizingValueInput) Validate() error {
 	return validation.ValidateStruct(&input,
 		validation.Field(&input.SessionID, validation.Required),
-		validation.Field(&input.SizingType, validation.In(
-			live.SizingTypeStoryPoints,
-			live.SizingTypeTShirt,
-		)),
+		validation.Field(&input.DeckID, validation.Required),
 		validation.Field(&input.SizingValue, validation.Required),
 	)
 }
+
+func (input PasteBacklogInput) Validate() error {
+	return validation.ValidateStruct(&input,
+		validation.Field(&input.SessionID, validation.Required),
+		validation.Field(&input.Items, validation.Required, validation.By(validateBacklog)),
+	)
+}
+
+func (input BacklogInput) Validate() error {
+	return validation.ValidateStruct(&input,
+		validation.Field(&input.SessionID, validation.Required),
+		validation.Field(&input.Items, validation.Required, validation.Length(1, maxBacklogItems)),
+	)
+}
+
+func (input BacklogItemInput) Validate() error {
+	return validation.ValidateStruct(&input,
+		validation.Field(&input.Summary, validation.Required, validation.RuneLength(1, maxTicketFieldSize)),
+		validation.Field(&input.URL, validation.RuneLength(0, maxTicketFieldSize), validation.By(validateURL)),
+		validation.Field(&input.Key, validation.RuneLength(0, maxKeySize)),
+	)
+}
+
+func (input ImportBacklogInput) Validate() error {
+	return validation.ValidateStruct(&input,
+		validation.Field(&input.SessionID, validation.Required),
+		validation.Field(&input.Format, validation.In(formatAuto, formatCSV, formatGitHub, formatGitLab, formatJira)),
+		validation.Field(&input.Content, validation.Length(0, maxImportSize)),
+	)
+}
+
+func (input BacklogItemParams) Validate() error {
+	return validation.ValidateStruct(&input,
+		validation.Field(&input.SessionID, validation.Required),
+		validation.Field(&input.ItemID, validation.Required),
+	)
+}
+
+func (input MoveBacklogItemInput) Validate() error {
+	return validation.ValidateStruct(&input,
+		validation.Field(&input.SessionID, validation.Required),
+		validation.Field(&input.ItemID, validation.Required),
+		validation.Field(&input.Position, validation.Min(0)),
+	)
+}
+
+func (input GetDeckInput) Validate() error {
+	return validation.ValidateStruct(&input,
+		validation.Field(&input.ID, validation.Required),
+	)
+}
+
+func (input DeckInput) Validate() error {
+	return validation.ValidateStruct(&input,
+		validation.Field(&input.Name, validation.Required, validation.RuneLength(1, maxDeckNameSize)),
+		validation.Field(&input.Cards, validation.Required, validation.By(validateCards)),
+	)
+}
+
+func validateBacklog(value any) error {
+	items := parseBacklog(value.(string)) //nolint:forcetypeassert
+
+	return validation.Validate(items, validation.Required, validation.Length(1, maxBacklogItems))
+}
+
+func validateURL(value any) error {
+	s, _ := value.(string)
+	if s == "" {
+		return nil
+	}
+
+	if u, err := url.ParseRequestURI(s); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return errors.New("must be a valid http(s) URL")
+	}
+
+	return nil
+}
+
+func validateCards(value any) error {
+	_, err := deck.ParseCards(value.(string)) //nolint:forcetypeassert
+
+	return err
+}
@@ -3,41 +3,74 @@ package session
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/MartyHub/size-it/internal"
 	"github.com/MartyHub/size-it/internal/db"
 	"github.com/MartyHub/size-it/internal/db/sqlc"
+	"github.com/MartyHub/size-it/internal/pubsub"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const (
+	maxTeamSessions      = 50
+	recentSessionsPeriod = 30 * 24 * time.Hour
+)
+
 type service struct {
-	clk  internal.Clock
-	repo *db.Repository
+	clk    internal.Clock
+	pubsub *pubsub.Bus
+	repo   *db.Repository
 }
 
-func newService(clk internal.Clock, repo *db.Repository) *service {
+func newService(clk internal.Clock, ps *pubsub.Bus, repo *db.Repository) *service {
 	return &service{
-		clk:  clk,
-		repo: repo,
+		clk:    clk,
+		pubsub: ps,
+		repo:   repo,
 	}
 }
 
-func (svc *service) create(ctx context.Context, input CreateOrJoinSessionInput) (Session, error) {
+func (svc *service) create(ctx context.Context, input CreateOrJoinSessionInput, usr internal.User) (Session, error) {
 	id, err := db.NewID()
 	if err != nil {
 		return Session{}, err
 	}
 
+	scheduledAt, err := input.scheduledAt()
+	if err != nil {
+		return Session{}, fmt.Errorf("%w: scheduled at: %s", internal.ErrInvalidInput, err.Error())
+	}
+
 	entity, err := svc.repo.CreateSession(ctx, sqlc.CreateSessionParams{
-		ID:        id,
-		Team:      input.Team,
-		CreatedAt: pgtype.Timestamp{Time: svc.clk.Now(), Valid: true},
+		ID:              id,
+		Team:            input.Team,
+		FacilitatorID:   usr.ID,
+		AutoReveal:      input.AutoReveal,
+		AutoRevealDelay: int32(input.AutoRevealDelay), //nolint:gosec
+		Name:            strings.TrimSpace(input.Name),
+		Description:     strings.TrimSpace(input.Description),
+		Sprint:          strings.TrimSpace(input.Sprint),
+		ScheduledAt:     pgtype.Timestamp{Time: scheduledAt, Valid: !scheduledAt.IsZero()},
+		CreatedAt:       pgtype.Timestamp{Time: svc.clk.Now(), Valid: true},
 	})
 	if err != nil {
 		return Session{}, err
 	}
 
-	return toSession(entity), nil
+	res := toSession(entity)
+
+	svc.pubsub.Publish(ctx, pubsub.Event{
+		Type:      pubsub.SessionCreated,
+		Team:      res.Team,
+		SessionID: res.ID,
+		CreatedAt: res.CreatedAt,
+		Data:      res,
+	})
+
+	return res, nil
 }
 
 func (svc *service) get(ctx context.Context, id string) (Session, error) {
@@ -53,6 +86,131 @@ func (svc *service) get(ctx context.Context, id string) (Session, error) {
 	return toSession(entity), nil
 }
 
+// archive hides a closed session from lists, facilitator only.
+func (svc *service) archive(ctx context.Context, id string, usr internal.User) (Session, error) {
+	session, err := svc.get(ctx, id)
+	if err != nil {
+		return Session{}, err
+	}
+
+	if session.FacilitatorID != usr.ID {
+		return Session{}, fmt.Errorf("%w: %s is not facilitator of session %s", internal.ErrUnauthorized, usr.Name, id)
+	}
+
+	if !session.Closed() {
+		return Session{}, fmt.Errorf("%w: session %s must be closed to be archived", internal.ErrInvalidInput, id)
+	}
+
+	closedAt := pgtype.Timestamp{Time: svc.clk.Now(), Valid: true}
+
+	if session.ClosedAt != nil {
+		closedAt.Time = *session.ClosedAt
+	}
+
+	if err = svc.repo.UpdateSessionStatus(ctx, sqlc.UpdateSessionStatusParams{
+		Status:   db.SessionArchived,
+		ClosedAt: closedAt,
+		ID:       id,
+	}); err != nil {
+		return Session{}, err
+	}
+
+	session.Status = db.SessionArchived
+
+	return session, nil
+}
+
+// tickets returns the tickets saved during the session, along with their votes.
+func (svc *service) tickets(ctx context.Context, sessionID string) ([]Ticket, error) {
+	tickets, err := svc.repo.SessionTickets(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	votes, err := svc.repo.SessionVotes(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	votesByTicketID := make(map[int64][]Vote)
+
+	for _, vote := range votes {
+		votesByTicketID[vote.TicketID] = append(votesByTicketID[vote.TicketID], toVote(vote))
+	}
+
+	res := make([]Ticket, len(tickets))
+
+	for i, tck := range tickets {
+		res[i] = toTicket(tck, votesByTicketID[tck.ID])
+	}
+
+	return res, nil
+}
+
+func (svc *service) summary(ctx context.Context, id string) (SummaryOutput, error) {
+	session, err := svc.get(ctx, id)
+	if err != nil {
+		return SummaryOutput{}, err
+	}
+
+	tickets, err := svc.tickets(ctx, id)
+	if err != nil {
+		return SummaryOutput{}, err
+	}
+
+	return SummaryOutput{Session: session, Tickets: tickets}, nil
+}
+
+// teamSessions lists sessions of the team scheduled later, soonest first,
+// then those created or scheduled during the last weeks, latest first.
+func (svc *service) teamSessions(ctx context.Context, team string) (TeamSessionsOutput, error) {
+	now := svc.clk.Now()
+
+	entities, err := svc.repo.TeamSessions(ctx, sqlc.TeamSessionsParams{
+		Team:        team,
+		Since:       pgtype.Timestamp{Time: now.Add(-recentSessionsPeriod), Valid: true},
+		MaxSessions: maxTeamSessions,
+	})
+	if err != nil {
+		return TeamSessionsOutput{}, err
+	}
+
+	res := TeamSessionsOutput{
+		Upcoming: []Session{},
+		Recent:   []Session{},
+	}
+
+	for _, entity := range entities {
+		session := toSession(entity)
+
+		if session.Open() && session.ScheduledAt != nil && session.ScheduledAt.After(now) {
+			res.Upcoming = append(res.Upcoming, session)
+		} else {
+			res.Recent = append(res.Recent, session)
+		}
+	}
+
+	slices.Reverse(res.Upcoming)
+
+	return res, nil
+}
+
+// ticketCounts returns the number of tickets saved during each of the given sessions.
+func (svc *service) ticketCounts(ctx context.Context, sessionIDs []string) (map[string]int, error) {
+	rows, err := svc.repo.SessionTicketCounts(ctx, sessionIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[string]int, len(rows))
+
+	for _, row := range rows {
+		res[row.SessionID] = int(row.Tickets)
+	}
+
+	return res, nil
+}
+
 func (svc *service) teams(ctx context.Context) ([]string, error) {
 	teams, err := svc.repo.Teams(ctx)
 	if err != nil {
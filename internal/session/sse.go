@@ -1,20 +1,26 @@
 package session
 
 import (
+	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/MartyHub/size-it/internal"
 	"github.com/MartyHub/size-it/internal/live"
+	"github.com/MartyHub/size-it/internal/metrics"
 	"github.com/labstack/echo/v4"
 )
 
 type handlerSSE struct {
-	events  chan live.Event
-	done    <-chan struct{}
-	session Session
-	usr     internal.User
-
-	svc *live.Service
+	events    chan live.Event
+	done      <-chan struct{}
+	heartbeat time.Duration
+	retry     time.Duration
+	session   Session
+	usr       internal.User
+
+	connections *metrics.Gauge
+	svc         *live.Service
 }
 
 func (hdl *handlerSSE) handle(c echo.Context) error {
@@ -22,6 +28,12 @@ func (hdl *handlerSSE) handle(c echo.Context) error {
 		return err
 	}
 
+	hdl.connections.Inc()
+	defer hdl.connections.Dec()
+
+	ticker := time.NewTicker(hdl.heartbeat)
+	defer ticker.Stop()
+
 	for {
 		select {
 		case <-hdl.done:
@@ -49,6 +61,13 @@ func (hdl *handlerSSE) handle(c echo.Context) error {
 			if err := hdl.writeEvent(c, evt); err != nil {
 				hdl.svc.Leave(hdl.session.ID, hdl.usr)
 
+				return err
+			}
+		case <-ticker.C:
+			// keeps idle connections open through proxies
+			if err := hdl.write(c, ": heartbeat\n\n"); err != nil {
+				hdl.svc.Leave(hdl.session.ID, hdl.usr)
+
 				return err
 			}
 		}
@@ -62,13 +81,34 @@ func (hdl *handlerSSE) initSSE(c echo.Context) error {
 	header.Set(echo.HeaderCacheControl, "no-cache")
 	header.Set(echo.HeaderConnection, "keep-alive")
 
-	if err := hdl.svc.Join(c.Request().Context(), hdl.session.ID, hdl.usr, hdl.events); err != nil {
+	// EventSource sends the ID of the last event received when reconnecting
+	lastEventID := c.Request().Header.Get("Last-Event-ID")
+
+	if err := hdl.svc.Join(c.Request().Context(), hdl.session.ID, hdl.usr, hdl.events, lastEventID); err != nil {
+		return err
+	}
+
+	if err := hdl.write(c, fmt.Sprintf("retry: %d\n\n", hdl.retry.Milliseconds())); err != nil {
+		hdl.svc.Leave(hdl.session.ID, hdl.usr)
+
 		return err
 	}
 
 	return nil
 }
 
+func (hdl *handlerSSE) write(c echo.Context, s string) error {
+	w := c.Response()
+
+	if _, err := w.Write([]byte(s)); err != nil {
+		return err
+	}
+
+	w.Flush()
+
+	return nil
+}
+
 func (hdl *handlerSSE) writeEvent(c echo.Context, evt live.Event) error {
 	w := c.Response()
 
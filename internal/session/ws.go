@@ -0,0 +1,261 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/MartyHub/size-it/internal"
+	"github.com/MartyHub/size-it/internal/live"
+	"github.com/MartyHub/size-it/internal/metrics"
+	"github.com/invopop/validation"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/net/websocket"
+)
+
+// Actions sent by WebSocket clients.
+const (
+	actionPong         = "pong"
+	actionReset        = "reset"
+	actionReveal       = "reveal"
+	actionUpdateTicket = "updateTicket"
+	actionVote         = "vote"
+)
+
+// Types of messages sent to WebSocket clients.
+const (
+	messageAck   = "ack"
+	messageError = "error"
+	messageEvent = "event"
+	messagePing  = "ping"
+)
+
+type (
+	// WSAction is sent by WebSocket clients, its optional ID is echoed in the reply.
+	WSAction struct {
+		ID     string `json:"id,omitempty"`
+		Action string `json:"action"`
+
+		DeckID  int64  `json:"deckId,omitempty"`
+		Value   string `json:"value,omitempty"`
+		Show    bool   `json:"show,omitempty"`
+		Summary string `json:"summary,omitempty"`
+		URL     string `json:"url,omitempty"`
+	}
+
+	// WSMessage is sent to WebSocket clients: a rendered component, a ping to answer with a pong,
+	// or the reply to an action.
+	WSMessage struct {
+		Type   string `json:"type"`
+		ID     string `json:"id,omitempty"`
+		Action string `json:"action,omitempty"`
+		Event  string `json:"event,omitempty"`
+		Data   string `json:"data,omitempty"`
+		Error  string `json:"error,omitempty"`
+	}
+
+	// handlerWS carries the events of handlerSSE, along with actions of the user.
+	handlerWS struct {
+		events    chan live.Event
+		replies   chan WSMessage
+		done      <-chan struct{}
+		heartbeat time.Duration
+		session   Session
+		usr       internal.User
+
+		connections *metrics.Gauge
+		svc         *live.Service
+	}
+)
+
+func (action WSAction) Validate() error {
+	return validation.ValidateStruct(&action,
+		validation.Field(&action.Action, validation.Required, validation.In(
+			actionPong, actionReset, actionReveal, actionUpdateTicket, actionVote,
+		)),
+		validation.Field(&action.DeckID, validation.When(action.Action == actionVote, validation.Required)),
+		validation.Field(&action.Value, validation.When(action.Action == actionVote, validation.Required)),
+	)
+}
+
+func (hdl *handler) getSessionWS(c echo.Context) error {
+	input, err := internal.Bind[GetSessionInput](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	session, err := hdl.svc.get(ctx, input.ID)
+	if err != nil {
+		return err
+	}
+
+	usr, err := internal.GetUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	hdlWS := &handlerWS{
+		events:      make(chan live.Event, sseBufferSize),
+		replies:     make(chan WSMessage, sseBufferSize),
+		done:        hdl.done,
+		heartbeat:   hdl.heartbeat,
+		session:     session,
+		usr:         usr,
+		connections: hdl.wsConnections,
+		svc:         hdl.event,
+	}
+
+	websocket.Server{
+		Handshake: checkSameOrigin,
+		Handler:   hdlWS.handle,
+	}.ServeHTTP(c.Response(), c.Request())
+
+	return nil
+}
+
+// checkSameOrigin rejects browsers connecting from another site with the cookie of the user.
+// Other clients don't send any Origin header.
+func checkSameOrigin(_ *websocket.Config, req *http.Request) error {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || u.Host != req.Host {
+		return fmt.Errorf("%w: origin %s", internal.ErrUnauthorized, origin)
+	}
+
+	return nil
+}
+
+func (hdl *handlerWS) handle(conn *websocket.Conn) {
+	ctx := conn.Request().Context()
+
+	if err := hdl.svc.Join(ctx, hdl.session.ID, hdl.usr, hdl.events, ""); err != nil {
+		_ = websocket.JSON.Send(conn, WSMessage{Type: messageError, Error: err.Error()})
+
+		return
+	}
+
+	hdl.connections.Inc()
+	defer hdl.connections.Dec()
+
+	closed := make(chan struct{})
+
+	go hdl.receive(ctx, conn, closed)
+
+	ticker := time.NewTicker(hdl.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		var msg WSMessage
+
+		select {
+		case <-hdl.done:
+			slog.Info("Server is shutting down, stopping WebSocket...",
+				slog.String(internal.LogKeyUser, hdl.usr.Name),
+				slog.String(internal.LogKeySession, hdl.session.ID),
+			)
+
+			return
+		case <-closed:
+			// client is gone
+			hdl.svc.Leave(hdl.session.ID, hdl.usr)
+
+			return
+		case evt, ok := <-hdl.events:
+			if !ok {
+				slog.Info("User left session without notice",
+					slog.String(internal.LogKeySession, hdl.session.ID),
+					slog.String(internal.LogKeyUser, hdl.usr.Name),
+				)
+
+				return
+			}
+
+			msg = WSMessage{Type: messageEvent, Event: evt.Kind, Data: string(evt.Data)}
+		case msg = <-hdl.replies:
+		case <-ticker.C:
+			msg = WSMessage{Type: messagePing}
+		}
+
+		if err := websocket.JSON.Send(conn, msg); err != nil {
+			hdl.svc.Leave(hdl.session.ID, hdl.usr)
+
+			return
+		}
+	}
+}
+
+// receive runs actions until the client closes the connection, or misses two heartbeats.
+func (hdl *handlerWS) receive(ctx context.Context, conn *websocket.Conn, closed chan<- struct{}) {
+	defer close(closed)
+
+	for {
+		if err := conn.SetReadDeadline(time.Now().Add(2 * hdl.heartbeat)); err != nil { //nolint:mnd
+			return
+		}
+
+		var action WSAction
+
+		if err := websocket.JSON.Receive(conn, &action); err != nil && !isJSONError(err) {
+			return
+		}
+
+		if action.Action == actionPong {
+			continue
+		}
+
+		reply := WSMessage{Type: messageAck, ID: action.ID, Action: action.Action}
+
+		if err := hdl.run(ctx, action); err != nil {
+			reply.Type = messageError
+			reply.Error = err.Error()
+		}
+
+		select {
+		case hdl.replies <- reply:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (hdl *handlerWS) run(ctx context.Context, action WSAction) error {
+	if err := action.Validate(); err != nil {
+		return fmt.Errorf("%w: %s", internal.ErrInvalidInput, err.Error())
+	}
+
+	sessionID := hdl.session.ID
+
+	switch action.Action {
+	case actionReset:
+		return hdl.svc.ResetSession(ctx, sessionID, hdl.usr)
+	case actionReveal:
+		return hdl.svc.Reveal(ctx, sessionID, action.Show, hdl.usr)
+	case actionUpdateTicket:
+		return hdl.svc.UpdateTicket(ctx, sessionID, action.Summary, action.URL, hdl.usr)
+	case actionVote:
+		return hdl.svc.SetSizingValue(ctx, sessionID, action.DeckID, action.Value, hdl.usr)
+	}
+
+	return nil
+}
+
+// isJSONError reports whether a message could not be decoded, in which case the connection is still usable.
+func isJSONError(err error) bool {
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+
+	return errors.As(err, &syntaxErr) || errors.As(err, &typeErr)
+}
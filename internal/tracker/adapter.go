@@ -0,0 +1,152 @@
+package tracker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Kinds of issue trackers.
+const (
+	KindGitHub = "github"
+	KindGitLab = "gitlab"
+	KindJira   = "jira"
+)
+
+const maxResponseSize = 512
+
+type (
+	// Adapter reads summaries of the issues of a tracker, and writes their estimates.
+	Adapter interface {
+		// Issue returns the issue at the given URL, if it belongs to the tracker.
+		Issue(u *url.URL) (Issue, bool)
+		Summary(ctx context.Context, issue Issue) (string, error)
+		SetEstimate(ctx context.Context, issue Issue, est Estimate) error
+	}
+
+	// Issue identifies an issue in a tracker: the project is empty for Jira, as keys are unique.
+	Issue struct {
+		Project string
+		Key     string
+	}
+
+	// Estimate is the value of the card a ticket has been sized with, and its weight if any.
+	Estimate struct {
+		Value  string
+		Weight *float64
+	}
+
+	// settings are the ones of a tracker, with its credentials.
+	settings struct {
+		baseURL  *url.URL
+		username string
+		token    string
+		field    string
+	}
+)
+
+// Kinds returns the kinds of trackers adapters exist for.
+func Kinds() []string {
+	return []string{KindJira, KindGitLab, KindGitHub}
+}
+
+func (issue Issue) String() string {
+	if issue.Project == "" {
+		return issue.Key
+	}
+
+	return issue.Project + "#" + issue.Key
+}
+
+// Number returns the weight of the estimate, or its value if it is a number.
+func (est Estimate) Number() (float64, error) {
+	if est.Weight != nil {
+		return *est.Weight, nil
+	}
+
+	res, err := strconv.ParseFloat(est.Value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("estimate %s is not a number", est.Value)
+	}
+
+	return res, nil
+}
+
+func newAdapter(kind string, cfg settings, client *http.Client) (Adapter, error) { //nolint:ireturn
+	switch kind {
+	case KindGitHub:
+		return newGitHub(cfg, client), nil
+	case KindGitLab:
+		return newGitLab(cfg, client), nil
+	case KindJira:
+		return newJira(cfg, client), nil
+	}
+
+	return nil, fmt.Errorf("unknown tracker kind %s", kind)
+}
+
+// sameSite reports whether u is on the site of the tracker, including its path if any.
+func (cfg settings) sameSite(u *url.URL) bool {
+	return strings.EqualFold(u.Host, cfg.baseURL.Host) &&
+		strings.HasPrefix(u.Path, strings.TrimSuffix(cfg.baseURL.Path, "/"))
+}
+
+// relativePath returns the path of u on the site of the tracker, without leading and trailing slashes.
+func (cfg settings) relativePath(u *url.URL) string {
+	return strings.Trim(strings.TrimPrefix(u.Path, strings.TrimSuffix(cfg.baseURL.Path, "/")), "/")
+}
+
+// call sends a JSON request, failing unless the response status is 2xx.
+// The response body is decoded into res unless nil.
+func call(client *http.Client, req *http.Request, res any) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+
+		return fmt.Errorf("%s %s: %s %s", req.Method, req.URL, resp.Status, bytes.TrimSpace(body))
+	}
+
+	if res == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(res)
+}
+
+func newRequest(ctx context.Context, method, u string, body any) (*http.Request, error) {
+	var rdr io.Reader
+
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+
+		rdr = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, rdr)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return req, nil
+}
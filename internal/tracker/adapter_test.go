@@ -0,0 +1,136 @@
+package tracker
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+const testToken = "token"
+
+type (
+	// mockTracker answers requests by their method and escaped path, recording them.
+	mockTracker struct {
+		*httptest.Server
+
+		mu        sync.Mutex
+		requests  []request
+		responses map[string]string
+	}
+
+	request struct {
+		method string
+		path   string
+		header http.Header
+		body   map[string]any
+	}
+)
+
+// newMockTracker answers with the given JSON bodies, keyed by method and path such as "GET /issues/1", or else 204.
+func newMockTracker(t *testing.T, responses map[string]string) *mockTracker {
+	t.Helper()
+
+	res := &mockTracker{responses: responses}
+	res.Server = httptest.NewServer(http.HandlerFunc(res.serve))
+
+	t.Cleanup(res.Close)
+
+	return res
+}
+
+func (trk *mockTracker) serve(w http.ResponseWriter, r *http.Request) {
+	req := request{method: r.Method, path: r.URL.EscapedPath(), header: r.Header}
+
+	if data, _ := io.ReadAll(r.Body); len(data) > 0 {
+		_ = json.Unmarshal(data, &req.body)
+	}
+
+	trk.mu.Lock()
+	trk.requests = append(trk.requests, req)
+	body, found := trk.responses[req.method+" "+req.path]
+	trk.mu.Unlock()
+
+	if !found {
+		w.WriteHeader(http.StatusNoContent)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	_, _ = io.WriteString(w, body)
+}
+
+func (trk *mockTracker) received() []request {
+	trk.mu.Lock()
+	defer trk.mu.Unlock()
+
+	return trk.requests
+}
+
+// settings returns the settings of a tracker at the given path of the mock.
+func (trk *mockTracker) settings(t *testing.T, path, username, field string) settings {
+	t.Helper()
+
+	u, err := url.Parse(trk.URL + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return settings{baseURL: u, username: username, token: testToken, field: field}
+}
+
+func mustParse(t *testing.T, s string) *url.URL {
+	t.Helper()
+
+	u, err := url.Parse(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return u
+}
+
+// checkRequests compares the method and path of requests with the wanted ones, such as "GET /issues/1".
+func checkRequests(t *testing.T, requests []request, want ...string) {
+	t.Helper()
+
+	if len(requests) != len(want) {
+		t.Fatalf("got %d requests, want %v", len(requests), want)
+	}
+
+	for i, req := range requests {
+		if got := req.method + " " + req.path; got != want[i] {
+			t.Errorf("request #%d = %s, want %s", i+1, got, want[i])
+		}
+	}
+}
+
+func weight(value float64) *float64 {
+	return &value
+}
+
+func TestEstimate_Number(t *testing.T) {
+	tests := []struct {
+		est     Estimate
+		want    float64
+		wantErr bool
+	}{
+		{est: Estimate{Value: "3"}, want: 3},
+		{est: Estimate{Value: "0.5"}, want: 0.5},
+		{est: Estimate{Value: "M", Weight: weight(5)}, want: 5},
+		{est: Estimate{Value: "M"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := tt.est.Number()
+
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("Number(%+v) = %v, %v", tt.est, got, err)
+		}
+	}
+}
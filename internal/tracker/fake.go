@@ -0,0 +1,71 @@
+package tracker
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Fake is an in-memory tracker owning every issue of its host, the key of an issue being its path.
+// It stands in for real trackers in tests.
+type Fake struct {
+	mu        sync.Mutex
+	host      string
+	estimates map[string]Estimate
+	summaries map[string]string
+}
+
+var _ Adapter = (*Fake)(nil)
+
+func NewFake(host string) *Fake {
+	return &Fake{
+		host:      host,
+		estimates: make(map[string]Estimate),
+		summaries: make(map[string]string),
+	}
+}
+
+// AddIssue makes Summary return the given summary for the issue.
+func (adp *Fake) AddIssue(key, summary string) {
+	adp.mu.Lock()
+	defer adp.mu.Unlock()
+
+	adp.summaries[key] = summary
+}
+
+func (adp *Fake) Issue(u *url.URL) (Issue, bool) {
+	key := strings.Trim(u.Path, "/")
+
+	if !strings.EqualFold(u.Host, adp.host) || key == "" {
+		return Issue{}, false
+	}
+
+	return Issue{Key: key}, true
+}
+
+func (adp *Fake) Summary(_ context.Context, issue Issue) (string, error) {
+	adp.mu.Lock()
+	defer adp.mu.Unlock()
+
+	return adp.summaries[issue.Key], nil
+}
+
+func (adp *Fake) SetEstimate(_ context.Context, issue Issue, est Estimate) error {
+	adp.mu.Lock()
+	defer adp.mu.Unlock()
+
+	adp.estimates[issue.Key] = est
+
+	return nil
+}
+
+// Estimate returns the last estimate set on the issue.
+func (adp *Fake) Estimate(key string) (Estimate, bool) {
+	adp.mu.Lock()
+	defer adp.mu.Unlock()
+
+	res, found := adp.estimates[key]
+
+	return res, found
+}
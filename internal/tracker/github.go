@@ -0,0 +1,113 @@
+package tracker
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	gitHubAPI         = "https://api.github.com"
+	gitHubHost        = "github.com"
+	gitHubLabelPrefix = "size: "
+)
+
+type (
+	// gitHub labels issues with the field followed by the estimate, replacing previous estimates,
+	// authenticated by a personal access token.
+	gitHub struct {
+		cfg    settings
+		api    string
+		client *http.Client
+	}
+
+	gitHubIssue struct {
+		Title string `json:"title"`
+	}
+
+	gitHubLabel struct {
+		Name string `json:"name"`
+	}
+)
+
+func newGitHub(cfg settings, client *http.Client) *gitHub {
+	if cfg.field == "" {
+		cfg.field = gitHubLabelPrefix
+	}
+
+	// GitHub Enterprise Server serves its REST API on the same host.
+	api := gitHubAPI
+
+	if !strings.EqualFold(cfg.baseURL.Host, gitHubHost) {
+		api = cfg.baseURL.JoinPath("api/v3").String()
+	}
+
+	return &gitHub{cfg: cfg, api: api, client: client}
+}
+
+// Issue reads URLs such as https://github.com/owner/repo/issues/42.
+func (adp *gitHub) Issue(u *url.URL) (Issue, bool) {
+	if !adp.cfg.sameSite(u) {
+		return Issue{}, false
+	}
+
+	parts := strings.Split(adp.cfg.relativePath(u), "/")
+	if len(parts) != 4 || parts[2] != "issues" || !isNumber(parts[3]) { //nolint:mnd
+		return Issue{}, false
+	}
+
+	return Issue{Project: parts[0] + "/" + parts[1], Key: parts[3]}, true
+}
+
+func (adp *gitHub) Summary(ctx context.Context, issue Issue) (string, error) {
+	var res gitHubIssue
+
+	if err := adp.do(ctx, http.MethodGet, adp.issueURL(issue), nil, &res); err != nil {
+		return "", err
+	}
+
+	return res.Title, nil
+}
+
+func (adp *gitHub) SetEstimate(ctx context.Context, issue Issue, est Estimate) error {
+	labelsURL := adp.issueURL(issue) + "/labels"
+	label := adp.cfg.field + est.Value
+
+	var labels []gitHubLabel
+
+	if err := adp.do(ctx, http.MethodGet, labelsURL, nil, &labels); err != nil {
+		return err
+	}
+
+	for _, lbl := range labels {
+		if lbl.Name == label {
+			return nil
+		}
+
+		if strings.HasPrefix(lbl.Name, adp.cfg.field) {
+			if err := adp.do(ctx, http.MethodDelete, labelsURL+"/"+url.PathEscape(lbl.Name), nil, nil); err != nil {
+				return err
+			}
+		}
+	}
+
+	return adp.do(ctx, http.MethodPost, labelsURL, map[string]any{"labels": []string{label}}, nil)
+}
+
+func (adp *gitHub) issueURL(issue Issue) string {
+	return adp.api + "/repos/" + issue.Project + "/issues/" + issue.Key
+}
+
+func (adp *gitHub) do(ctx context.Context, method, u string, body, res any) error {
+	req, err := newRequest(ctx, method, u, body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+adp.cfg.token)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	return call(adp.client, req, res)
+}
@@ -0,0 +1,97 @@
+package tracker
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestGitHub_Issue(t *testing.T) {
+	adp := newGitHub(settings{baseURL: mustParse(t, "https://github.com")}, http.DefaultClient)
+
+	tests := map[string]Issue{
+		"https://github.com/owner/repo/issues/42": {Project: "owner/repo", Key: "42"},
+		"https://github.com/owner/repo/pull/42":   {},
+		"https://github.com/owner/repo/issues":    {},
+		"https://gitlab.com/owner/repo/issues/42": {},
+	}
+
+	for raw, want := range tests {
+		issue, found := adp.Issue(mustParse(t, raw))
+
+		if found != (want != Issue{}) || issue != want {
+			t.Errorf("Issue(%s) = %+v, %t, want %+v", raw, issue, found, want)
+		}
+	}
+
+	if adp.api != gitHubAPI {
+		t.Errorf("api = %s, want %s", adp.api, gitHubAPI)
+	}
+}
+
+func TestGitHub_Summary(t *testing.T) {
+	trk := newMockTracker(t, map[string]string{
+		"GET /api/v3/repos/owner/repo/issues/42": `{"title": "Size it"}`,
+	})
+	adp := newGitHub(trk.settings(t, "", "", ""), trk.Client())
+
+	summary, err := adp.Summary(context.Background(), Issue{Project: "owner/repo", Key: "42"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if summary != "Size it" {
+		t.Errorf("summary = %s", summary)
+	}
+
+	if auth := trk.received()[0].header.Get("Authorization"); auth != "Bearer "+testToken {
+		t.Errorf("authorization = %s", auth)
+	}
+}
+
+func TestGitHub_SetEstimate(t *testing.T) {
+	const labels = "/api/v3/repos/owner/repo/issues/42/labels"
+
+	tests := []struct {
+		name   string
+		labels string
+		want   []string
+	}{
+		{
+			name:   "first estimate",
+			labels: `[{"name": "bug"}]`,
+			want:   []string{"GET " + labels, "POST " + labels},
+		},
+		{
+			name:   "new estimate",
+			labels: `[{"name": "bug"}, {"name": "size: 3"}, {"name": "size: M"}]`,
+			want:   []string{"GET " + labels, "DELETE " + labels + "/size:%203", "DELETE " + labels + "/size:%20M", "POST " + labels},
+		},
+		{
+			name:   "same estimate",
+			labels: `[{"name": "size: 5"}]`,
+			want:   []string{"GET " + labels},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trk := newMockTracker(t, map[string]string{"GET " + labels: tt.labels})
+			adp := newGitHub(trk.settings(t, "", "", ""), trk.Client())
+
+			if err := adp.SetEstimate(context.Background(), Issue{Project: "owner/repo", Key: "42"}, Estimate{Value: "5"}); err != nil {
+				t.Fatal(err)
+			}
+
+			requests := trk.received()
+
+			checkRequests(t, requests, tt.want...)
+
+			if last := requests[len(requests)-1]; last.method == http.MethodPost {
+				if added, _ := last.body["labels"].([]any); len(added) != 1 || added[0] != "size: 5" {
+					t.Errorf("labels = %v", last.body["labels"])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package tracker
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	gitLabWeight = "weight"
+
+	// gitLabLabelPrefix makes a scoped label, so that GitLab replaces the previous estimate.
+	gitLabLabelPrefix = "size::"
+)
+
+type (
+	// gitLab sets the weight of issues, or a label made of the field followed by the estimate,
+	// authenticated by a personal, group or project access token.
+	gitLab struct {
+		cfg    settings
+		client *http.Client
+	}
+
+	gitLabIssue struct {
+		Title string `json:"title"`
+	}
+)
+
+func newGitLab(cfg settings, client *http.Client) *gitLab {
+	if cfg.field == "" {
+		cfg.field = gitLabLabelPrefix
+	}
+
+	return &gitLab{cfg: cfg, client: client}
+}
+
+// Issue reads URLs such as https://gitlab.com/group/project/-/issues/42.
+func (adp *gitLab) Issue(u *url.URL) (Issue, bool) {
+	if !adp.cfg.sameSite(u) {
+		return Issue{}, false
+	}
+
+	project, rest, found := strings.Cut(adp.cfg.relativePath(u), "/-/")
+	if !found || project == "" {
+		return Issue{}, false
+	}
+
+	kind, iid, found := strings.Cut(rest, "/")
+	if !found || (kind != "issues" && kind != "work_items") || !isNumber(iid) {
+		return Issue{}, false
+	}
+
+	return Issue{Project: project, Key: iid}, true
+}
+
+func (adp *gitLab) Summary(ctx context.Context, issue Issue) (string, error) {
+	req, err := newRequest(ctx, http.MethodGet, adp.issueURL(issue), nil)
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("PRIVATE-TOKEN", adp.cfg.token)
+
+	var res gitLabIssue
+
+	if err = call(adp.client, req, &res); err != nil {
+		return "", err
+	}
+
+	return res.Title, nil
+}
+
+func (adp *gitLab) SetEstimate(ctx context.Context, issue Issue, est Estimate) error {
+	body := map[string]any{"add_labels": adp.cfg.field + est.Value}
+
+	if adp.cfg.field == gitLabWeight {
+		weight, err := est.Number()
+		if err != nil {
+			return err
+		}
+
+		body = map[string]any{gitLabWeight: int(math.Round(weight))}
+	}
+
+	req, err := newRequest(ctx, http.MethodPut, adp.issueURL(issue), body)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("PRIVATE-TOKEN", adp.cfg.token)
+
+	return call(adp.client, req, nil)
+}
+
+// issueURL returns the REST API URL of the issue.
+// The path of the project is a single escaped segment, that JoinPath would escape twice.
+func (adp *gitLab) issueURL(issue Issue) string {
+	return strings.TrimSuffix(adp.cfg.baseURL.String(), "/") +
+		"/api/v4/projects/" + url.PathEscape(issue.Project) + "/issues/" + issue.Key
+}
+
+func isNumber(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
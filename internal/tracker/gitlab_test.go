@@ -0,0 +1,98 @@
+package tracker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGitLab_Issue(t *testing.T) {
+	adp := newGitLab(settings{baseURL: mustParse(t, "https://gitlab.example.com/gitlab")}, http.DefaultClient)
+
+	tests := map[string]Issue{
+		"https://gitlab.example.com/gitlab/group/project/-/issues/42":        {Project: "group/project", Key: "42"},
+		"https://gitlab.example.com/gitlab/group/sub/project/-/work_items/7": {Project: "group/sub/project", Key: "7"},
+		"https://gitlab.example.com/gitlab/group/project/-/merge_requests/1": {},
+		"https://gitlab.example.com/gitlab/group/project/-/issues/new":       {},
+		"https://gitlab.example.com/other/group/project/-/issues/42":         {},
+	}
+
+	for raw, want := range tests {
+		issue, found := adp.Issue(mustParse(t, raw))
+
+		if found != (want != Issue{}) || issue != want {
+			t.Errorf("Issue(%s) = %+v, %t, want %+v", raw, issue, found, want)
+		}
+	}
+}
+
+func TestGitLab_Summary(t *testing.T) {
+	trk := newMockTracker(t, map[string]string{
+		"GET /api/v4/projects/group%2Fproject/issues/42": `{"title": "Size it"}`,
+	})
+	adp := newGitLab(trk.settings(t, "", "", ""), trk.Client())
+
+	summary, err := adp.Summary(context.Background(), Issue{Project: "group/project", Key: "42"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if summary != "Size it" {
+		t.Errorf("summary = %s", summary)
+	}
+
+	if token := trk.received()[0].header.Get("PRIVATE-TOKEN"); token != testToken {
+		t.Errorf("token = %s", token)
+	}
+}
+
+func TestGitLab_SetEstimate(t *testing.T) {
+	tests := []struct {
+		name  string
+		field string
+		est   Estimate
+		want  map[string]any
+	}{
+		{name: "scoped label", est: Estimate{Value: "XL"}, want: map[string]any{"add_labels": "size::XL"}},
+		{name: "other label", field: "points/", est: Estimate{Value: "3"}, want: map[string]any{"add_labels": "points/3"}},
+		{name: "weight", field: gitLabWeight, est: Estimate{Value: "2.6"}, want: map[string]any{"weight": 3.0}},
+		{name: "weight of card", field: gitLabWeight, est: Estimate{Value: "M", Weight: weight(5)}, want: map[string]any{"weight": 5.0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trk := newMockTracker(t, nil)
+			adp := newGitLab(trk.settings(t, "/gitlab/", "", tt.field), trk.Client())
+
+			if err := adp.SetEstimate(context.Background(), Issue{Project: "group/project", Key: "42"}, tt.est); err != nil {
+				t.Fatal(err)
+			}
+
+			requests := trk.received()
+
+			checkRequests(t, requests, "PUT /gitlab/api/v4/projects/group%2Fproject/issues/42")
+
+			if body := requests[0].body; len(body) != len(tt.want) {
+				t.Errorf("body = %v, want %v", body, tt.want)
+			}
+
+			for key, value := range tt.want {
+				if got := requests[0].body[key]; got != value {
+					t.Errorf("%s = %v, want %v", key, got, value)
+				}
+			}
+		})
+	}
+}
+
+func TestGitLab_SetEstimate_fails(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	adp := newGitLab(settings{baseURL: mustParse(t, srv.URL), token: testToken}, srv.Client())
+
+	if err := adp.SetEstimate(context.Background(), Issue{Project: "group/project", Key: "42"}, Estimate{Value: "3"}); err == nil {
+		t.Error("estimate set on a missing issue")
+	}
+}
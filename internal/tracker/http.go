@@ -0,0 +1,172 @@
+package tracker
+
+import (
+	"net/http"
+	"path"
+
+	"github.com/MartyHub/size-it/internal"
+	"github.com/MartyHub/size-it/internal/openapi"
+	"github.com/MartyHub/size-it/internal/server"
+	"github.com/labstack/echo/v4"
+)
+
+const tagTrackers = "trackers"
+
+func Register(srv *server.Server) {
+	hdl := &handler{
+		path: srv.Cfg.Path,
+		svc:  NewService(srv.Cfg, srv.Clk, srv.Repo, srv.Cookies),
+	}
+
+	srv.PubSub.Subscribe(hdl.svc.sized)
+	srv.Event.SetSummaryResolver(hdl.svc)
+
+	srv.GET("/trackers", hdl.list)
+	srv.POST("/trackers", hdl.create)
+	srv.DELETE("/trackers/:trackerID", hdl.delete)
+
+	srv.API(http.MethodGet, "/api/v1/trackers", hdl.apiList, openapi.Route{
+		Summary: "List issue trackers of the team of the user",
+		Tags:    []string{tagTrackers},
+		Output:  []Tracker{},
+	})
+	srv.API(http.MethodPost, "/api/v1/trackers", hdl.apiCreate, openapi.Route{
+		Summary: "Write estimates of sized tickets to the issues of a Jira, GitLab or GitHub tracker",
+		Tags:    []string{tagTrackers},
+		Input:   TrackerInput{},
+		Output:  Tracker{},
+		Status:  http.StatusCreated,
+	})
+	srv.API(http.MethodDelete, "/api/v1/trackers/:trackerID", hdl.apiDelete, openapi.Route{
+		Summary: "Delete an issue tracker",
+		Tags:    []string{tagTrackers},
+		Input:   TrackerParams{},
+		Status:  http.StatusNoContent,
+	})
+}
+
+type handler struct {
+	path string
+	svc  *Service
+}
+
+func (hdl *handler) list(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetTeamUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	trackers, err := hdl.svc.List(ctx, usr.Team)
+	if err != nil {
+		return err
+	}
+
+	return c.Render(http.StatusOK, "trackers.gohtml", map[string]any{
+		"kinds":    Kinds(),
+		"path":     hdl.path,
+		"trackers": trackers,
+		"user":     usr,
+	})
+}
+
+func (hdl *handler) create(c echo.Context) error {
+	input, err := internal.Bind[TrackerInput](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetTeamUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err = hdl.svc.Create(ctx, usr.Team, input); err != nil {
+		return err
+	}
+
+	return c.Redirect(http.StatusFound, path.Join(hdl.path, "trackers"))
+}
+
+// delete is called by htmx, which then follows the HX-Redirect header.
+func (hdl *handler) delete(c echo.Context) error {
+	input, err := internal.Bind[TrackerParams](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetTeamUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = hdl.svc.Delete(ctx, input.ID, usr.Team); err != nil {
+		return err
+	}
+
+	c.Response().Header().Set("HX-Redirect", path.Join(hdl.path, "trackers"))
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (hdl *handler) apiList(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetTeamUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	trackers, err := hdl.svc.List(ctx, usr.Team)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, trackers)
+}
+
+func (hdl *handler) apiCreate(c echo.Context) error {
+	input, err := internal.Bind[TrackerInput](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetTeamUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	res, err := hdl.svc.Create(ctx, usr.Team, input)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, res)
+}
+
+func (hdl *handler) apiDelete(c echo.Context) error {
+	input, err := internal.Bind[TrackerParams](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetTeamUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = hdl.svc.Delete(ctx, input.ID, usr.Team); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,95 @@
+package tracker
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// jiraStoryPoints is the field of story points in Jira Cloud team-managed projects.
+const jiraStoryPoints = "customfield_10016"
+
+var jiraKey = regexp.MustCompile(`^[A-Z][A-Z0-9_]*-[0-9]+$`)
+
+type (
+	// jira sets a numeric field of Jira Cloud issues, authenticated by an email and an API token.
+	jira struct {
+		cfg    settings
+		client *http.Client
+	}
+
+	jiraIssue struct {
+		Fields struct {
+			Summary string `json:"summary"`
+		} `json:"fields"`
+	}
+)
+
+func newJira(cfg settings, client *http.Client) *jira {
+	if cfg.field == "" {
+		cfg.field = jiraStoryPoints
+	}
+
+	return &jira{cfg: cfg, client: client}
+}
+
+// Issue reads the key of browse URLs, or of the issue selected in a board.
+func (adp *jira) Issue(u *url.URL) (Issue, bool) {
+	if !adp.cfg.sameSite(u) {
+		return Issue{}, false
+	}
+
+	key := u.Query().Get("selectedIssue")
+
+	if parts := strings.Split(adp.cfg.relativePath(u), "/"); len(parts) == 2 && parts[0] == "browse" { //nolint:mnd
+		key = parts[1]
+	}
+
+	if !jiraKey.MatchString(key) {
+		return Issue{}, false
+	}
+
+	return Issue{Key: key}, true
+}
+
+func (adp *jira) Summary(ctx context.Context, issue Issue) (string, error) {
+	u := adp.cfg.baseURL.JoinPath("rest/api/3/issue", issue.Key)
+	u.RawQuery = "fields=summary"
+
+	req, err := newRequest(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	req.SetBasicAuth(adp.cfg.username, adp.cfg.token)
+
+	var res jiraIssue
+
+	if err = call(adp.client, req, &res); err != nil {
+		return "", err
+	}
+
+	return res.Fields.Summary, nil
+}
+
+func (adp *jira) SetEstimate(ctx context.Context, issue Issue, est Estimate) error {
+	points, err := est.Number()
+	if err != nil {
+		return err
+	}
+
+	u := adp.cfg.baseURL.JoinPath("rest/api/3/issue", issue.Key)
+
+	req, err := newRequest(ctx, http.MethodPut, u.String(), map[string]any{
+		"fields": map[string]any{adp.cfg.field: points},
+	})
+	if err != nil {
+		return err
+	}
+
+	req.SetBasicAuth(adp.cfg.username, adp.cfg.token)
+
+	return call(adp.client, req, nil)
+}
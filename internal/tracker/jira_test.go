@@ -0,0 +1,98 @@
+package tracker
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestJira_Issue(t *testing.T) {
+	adp := newJira(settings{baseURL: mustParse(t, "https://acme.atlassian.net")}, http.DefaultClient)
+
+	tests := map[string]string{
+		"https://acme.atlassian.net/browse/SIZE-42":                                            "SIZE-42",
+		"https://acme.atlassian.net/jira/software/projects/SIZE/boards/1?selectedIssue=SIZE-7": "SIZE-7",
+		"https://acme.atlassian.net/browse/size-42":                                            "",
+		"https://acme.atlassian.net/browse":                                                    "",
+		"https://other.atlassian.net/browse/SIZE-42":                                           "",
+	}
+
+	for raw, want := range tests {
+		issue, found := adp.Issue(mustParse(t, raw))
+
+		if found != (want != "") || issue.Key != want {
+			t.Errorf("Issue(%s) = %+v, %t, want %s", raw, issue, found, want)
+		}
+	}
+}
+
+func TestJira_Summary(t *testing.T) {
+	trk := newMockTracker(t, map[string]string{
+		"GET /rest/api/3/issue/SIZE-42": `{"fields": {"summary": "Size it"}}`,
+	})
+	adp := newJira(trk.settings(t, "", "alice@example.com", ""), trk.Client())
+
+	summary, err := adp.Summary(context.Background(), Issue{Key: "SIZE-42"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if summary != "Size it" {
+		t.Errorf("summary = %s", summary)
+	}
+
+	req := trk.received()[0]
+
+	if username, token, ok := (&http.Request{Header: req.header}).BasicAuth(); !ok ||
+		username != "alice@example.com" || token != testToken {
+		t.Errorf("unexpected credentials %s %s", username, token)
+	}
+}
+
+func TestJira_SetEstimate(t *testing.T) {
+	tests := []struct {
+		name  string
+		field string
+		est   Estimate
+		want  string
+	}{
+		{name: "story points", est: Estimate{Value: "3"}, want: jiraStoryPoints},
+		{name: "weight of card", est: Estimate{Value: "M", Weight: weight(5)}, want: jiraStoryPoints},
+		{name: "other field", field: "customfield_10028", est: Estimate{Value: "8"}, want: "customfield_10028"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trk := newMockTracker(t, nil)
+			adp := newJira(trk.settings(t, "/jira", "alice@example.com", tt.field), trk.Client())
+
+			if err := adp.SetEstimate(context.Background(), Issue{Key: "SIZE-42"}, tt.est); err != nil {
+				t.Fatal(err)
+			}
+
+			requests := trk.received()
+
+			checkRequests(t, requests, "PUT /jira/rest/api/3/issue/SIZE-42")
+
+			fields, _ := requests[0].body["fields"].(map[string]any)
+			want, _ := tt.est.Number()
+
+			if len(fields) != 1 || fields[tt.want] != want {
+				t.Errorf("fields = %v, want %s: %v", fields, tt.want, want)
+			}
+		})
+	}
+}
+
+func TestJira_SetEstimate_rejectsText(t *testing.T) {
+	trk := newMockTracker(t, nil)
+	adp := newJira(trk.settings(t, "", "alice@example.com", ""), trk.Client())
+
+	if err := adp.SetEstimate(context.Background(), Issue{Key: "SIZE-42"}, Estimate{Value: "XL"}); err == nil {
+		t.Error("set a text estimate")
+	}
+
+	if len(trk.received()) > 0 {
+		t.Error("request sent")
+	}
+}
@@ -0,0 +1,145 @@
+package tracker
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/MartyHub/size-it/internal"
+	"github.com/MartyHub/size-it/internal/db/sqlc"
+	"github.com/invopop/validation"
+)
+
+const (
+	maxBaseURLSize    = 512
+	maxFieldSize      = 128
+	maxSyncStatusSize = 512
+	maxTokenSize      = 512
+	maxUsernameSize   = 128
+)
+
+type (
+	// Tracker is the integration of a team with an issue tracker, its token is never returned.
+	Tracker struct {
+		ID         int64      `json:"id"`
+		Kind       string     `json:"kind"`
+		BaseURL    string     `json:"baseUrl"`
+		Username   string     `json:"username,omitempty"`
+		Field      string     `json:"field,omitempty"`
+		DryRun     bool       `json:"dryRun"`
+		CreatedAt  time.Time  `json:"createdAt"`
+		SyncStatus string     `json:"syncStatus,omitempty"`
+		SyncedAt   *time.Time `json:"syncedAt,omitempty"`
+	}
+
+	// TrackerInput configures a tracker. The field is the one of story points for Jira,
+	// "weight" or the prefix of estimate labels for GitLab, and the prefix of estimate labels for GitHub.
+	TrackerInput struct {
+		Kind     string `form:"kind"     json:"kind"`
+		BaseURL  string `form:"baseUrl"  json:"baseUrl"`
+		Username string `form:"username" json:"username"`
+		Token    string `form:"token"    json:"token"`
+		Field    string `form:"field"    json:"field"`
+		DryRun   bool   `form:"dryRun"   json:"dryRun"`
+	}
+
+	TrackerParams struct {
+		ID int64 `param:"trackerID"`
+	}
+)
+
+func (input TrackerInput) Validate() error {
+	return validation.ValidateStruct(&input,
+		validation.Field(&input.Kind, validation.Required, validation.In(toAny(Kinds())...)),
+		validation.Field(&input.BaseURL,
+			validation.Required,
+			validation.RuneLength(1, maxBaseURLSize),
+			validation.By(validateURL),
+		),
+		validation.Field(&input.Username,
+			validation.When(input.Kind == KindJira, validation.Required),
+			validation.RuneLength(0, maxUsernameSize),
+		),
+		validation.Field(&input.Token, validation.Required, validation.Length(1, maxTokenSize)),
+		validation.Field(&input.Field, validation.RuneLength(0, maxFieldSize)),
+	)
+}
+
+func (params TrackerParams) Validate() error {
+	return validation.ValidateStruct(&params,
+		validation.Field(&params.ID, validation.Required),
+	)
+}
+
+func validateURL(value any) error {
+	s, _ := value.(string)
+
+	if u, err := url.ParseRequestURI(s); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("must be a valid http(s) URL")
+	}
+
+	return nil
+}
+
+func toAny(values []string) []any {
+	res := make([]any, len(values))
+
+	for i, value := range values {
+		res[i] = value
+	}
+
+	return res
+}
+
+func toTracker(entity sqlc.Tracker) Tracker {
+	res := Tracker{
+		ID:         entity.ID,
+		Kind:       entity.Kind,
+		BaseURL:    entity.BaseUrl,
+		Username:   entity.Username,
+		Field:      entity.Field,
+		DryRun:     entity.DryRun,
+		CreatedAt:  entity.CreatedAt.Time,
+		SyncStatus: entity.SyncStatus,
+	}
+
+	if entity.SyncedAt.Valid {
+		res.SyncedAt = &entity.SyncedAt.Time
+	}
+
+	return res
+}
+
+// toSettings parses the base URL, validated on creation, and decrypts the token.
+func toSettings(entity sqlc.Tracker, secrets *internal.CookieCodec) (settings, error) {
+	u, err := url.Parse(entity.BaseUrl)
+	if err != nil {
+		return settings{}, err
+	}
+
+	token, err := secrets.OpenSecret(entity.Token)
+	if err != nil {
+		return settings{}, fmt.Errorf("token of tracker %d: %w", entity.ID, err)
+	}
+
+	return settings{
+		baseURL:  u,
+		username: entity.Username,
+		token:    token,
+		field:    entity.Field,
+	}, nil
+}
+
+func truncate(s string, size int) string {
+	if runes := []rune(s); len(runes) > size {
+		return string(runes[:size])
+	}
+
+	return s
+}
+
+func normalizeBaseURL(s string) string {
+	return strings.TrimSuffix(strings.TrimSpace(s), "/")
+}
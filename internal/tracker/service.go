@@ -0,0 +1,227 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/MartyHub/size-it/internal"
+	"github.com/MartyHub/size-it/internal/db"
+	"github.com/MartyHub/size-it/internal/db/sqlc"
+	"github.com/MartyHub/size-it/internal/live"
+	"github.com/MartyHub/size-it/internal/pubsub"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type Service struct {
+	clk            internal.Clock
+	repo           *db.Repository
+	secrets        *internal.CookieCodec
+	summaryTimeout time.Duration
+	timeout        time.Duration
+	titles         *pageTitles
+	// adapter returns the adapter of a tracker, tests may replace it to return a Fake.
+	adapter func(entity sqlc.Tracker) (Adapter, error)
+}
+
+// NewService encrypts the tokens of trackers with secrets.
+func NewService(cfg internal.Config, clk internal.Clock, repo *db.Repository, secrets *internal.CookieCodec) *Service {
+	client := &http.Client{Timeout: cfg.TrackerTimeout}
+
+	return &Service{
+		clk:            clk,
+		repo:           repo,
+		secrets:        secrets,
+		summaryTimeout: cfg.SummaryTimeout,
+		timeout:        cfg.TrackerTimeout,
+		titles:         newPageTitles(cfg.SummaryHosts, cfg.SummaryTimeout),
+		adapter: func(entity sqlc.Tracker) (Adapter, error) {
+			cfg, err := toSettings(entity, secrets)
+			if err != nil {
+				return nil, err
+			}
+
+			return newAdapter(entity.Kind, cfg, client)
+		},
+	}
+}
+
+func (svc *Service) List(ctx context.Context, team string) ([]Tracker, error) {
+	entities, err := svc.repo.Trackers(ctx, team)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]Tracker, len(entities))
+
+	for i, entity := range entities {
+		res[i] = toTracker(entity)
+	}
+
+	return res, nil
+}
+
+func (svc *Service) Create(ctx context.Context, team string, input TrackerInput) (Tracker, error) {
+	baseURL := normalizeBaseURL(input.BaseURL)
+
+	token, err := svc.secrets.SealSecret(input.Token)
+	if err != nil {
+		return Tracker{}, err
+	}
+
+	entity, err := svc.repo.CreateTracker(ctx, sqlc.CreateTrackerParams{
+		Team:      team,
+		Kind:      input.Kind,
+		BaseUrl:   baseURL,
+		Username:  input.Username,
+		Token:     token,
+		Field:     input.Field,
+		DryRun:    input.DryRun,
+		CreatedAt: pgtype.Timestamp{Time: svc.clk.Now(), Valid: true},
+	})
+	if err != nil {
+		if db.IsErrUniqueViolation(err) {
+			return Tracker{}, fmt.Errorf("%w: tracker %s already exists", internal.ErrInvalidInput, baseURL)
+		}
+
+		return Tracker{}, err
+	}
+
+	slog.Info("Created tracker",
+		slog.Int64("tracker", entity.ID),
+		slog.String("team", team),
+		slog.String("kind", entity.Kind),
+		slog.Bool("dryRun", entity.DryRun),
+	)
+
+	return toTracker(entity), nil
+}
+
+func (svc *Service) Delete(ctx context.Context, id int64, team string) error {
+	entity, err := svc.repo.Tracker(ctx, id)
+	if err != nil {
+		if db.IsErrNoRows(err) {
+			return fmt.Errorf("%w: tracker %d", internal.ErrNotFound, id)
+		}
+
+		return err
+	}
+
+	if entity.Team != team {
+		return fmt.Errorf("%w: tracker %d", internal.ErrNotFound, id)
+	}
+
+	return svc.repo.DeleteTracker(ctx, id)
+}
+
+// Summary returns the summary of the issue at the given URL, read from the first tracker of the team it belongs to,
+// or else from the title of its web page if its host is allowed.
+// It returns an empty summary for other URLs.
+func (svc *Service) Summary(ctx context.Context, team, issueURL string) (string, error) {
+	u, err := url.Parse(issueURL)
+	if err != nil || u.Host == "" {
+		return "", nil //nolint:nilerr
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, svc.summaryTimeout)
+	defer cancel()
+
+	entities, err := svc.repo.Trackers(ctx, team)
+	if err != nil {
+		return "", err
+	}
+
+	for _, entity := range entities {
+		adp, err := svc.adapter(entity)
+		if err != nil {
+			return "", err
+		}
+
+		if issue, found := adp.Issue(u); found {
+			return adp.Summary(ctx, issue)
+		}
+	}
+
+	if !svc.titles.allowed(u) {
+		return "", nil
+	}
+
+	return svc.titles.title(ctx, u)
+}
+
+// SetEstimate writes the estimate to the issue at the given URL, using the first tracker of the team it belongs to.
+// It does nothing if the issue belongs to no tracker, and only records what it would do in dry-run mode.
+func (svc *Service) SetEstimate(ctx context.Context, team, issueURL string, est Estimate) error {
+	u, err := url.Parse(issueURL)
+	if err != nil || u.Host == "" {
+		return nil //nolint:nilerr
+	}
+
+	entities, err := svc.repo.Trackers(ctx, team)
+	if err != nil {
+		return err
+	}
+
+	for _, entity := range entities {
+		adp, err := svc.adapter(entity)
+		if err != nil {
+			return err
+		}
+
+		issue, found := adp.Issue(u)
+		if !found {
+			continue
+		}
+
+		return svc.setEstimate(ctx, entity, adp, issue, est)
+	}
+
+	return nil
+}
+
+func (svc *Service) setEstimate(ctx context.Context, entity sqlc.Tracker, adp Adapter, issue Issue, est Estimate) error {
+	status := fmt.Sprintf("Set estimate %s of %s", est.Value, issue)
+
+	var err error
+
+	if entity.DryRun {
+		status = fmt.Sprintf("Dry run: would have set estimate %s of %s", est.Value, issue)
+	} else if err = adp.SetEstimate(ctx, issue, est); err != nil {
+		status = fmt.Sprintf("Failed to set estimate %s of %s: %s", est.Value, issue, err.Error())
+	}
+
+	slog.Info(status, slog.Int64("tracker", entity.ID), slog.String("team", entity.Team))
+
+	if updateErr := svc.repo.UpdateTrackerSync(ctx, sqlc.UpdateTrackerSyncParams{
+		SyncStatus: truncate(status, maxSyncStatusSize),
+		SyncedAt:   pgtype.Timestamp{Time: svc.clk.Now(), Valid: true},
+		ID:         entity.ID,
+	}); updateErr != nil && err == nil {
+		err = updateErr
+	}
+
+	return err
+}
+
+// sized is a pubsub handler writing estimates of sized tickets asynchronously, as trackers may be slow.
+func (svc *Service) sized(_ context.Context, evt pubsub.Event) {
+	sizing, ok := evt.Data.(live.Sizing)
+	if !ok || evt.Type != pubsub.TicketSized || evt.Team == "" || sizing.Ticket.URL == "" {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), svc.timeout)
+		defer cancel()
+
+		if err := svc.SetEstimate(ctx, evt.Team, sizing.Ticket.URL, Estimate{
+			Value:  sizing.Ticket.SizingValue,
+			Weight: sizing.Weight,
+		}); err != nil {
+			internal.LogError("Failed to write estimate to tracker", err)
+		}
+	}()
+}
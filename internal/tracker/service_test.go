@@ -0,0 +1,137 @@
+package tracker
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MartyHub/size-it/internal"
+	"github.com/MartyHub/size-it/internal/db/dbtest"
+	"github.com/MartyHub/size-it/internal/db/sqlc"
+)
+
+const testHost = "tracker.example.com"
+
+// newTestService returns a service using the test database, whose trackers are all the given fake.
+// The fake is only returned once the token of the tracker has been decrypted.
+func newTestService(t *testing.T, fake *Fake) *Service {
+	t.Helper()
+
+	secrets, err := internal.NewCookieCodec(internal.Config{CookieKeys: []string{strings.Repeat("k", 32)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	svc := NewService(internal.Config{}, internal.NewFixedClock(time.Now().UTC()), dbtest.Repository(t), secrets)
+
+	svc.adapter = func(entity sqlc.Tracker) (Adapter, error) {
+		cfg, err := toSettings(entity, secrets)
+		if err != nil {
+			return nil, err
+		}
+
+		if cfg.token != testToken {
+			t.Errorf("token = %s, want %s", cfg.token, testToken)
+		}
+
+		return fake, nil
+	}
+
+	return svc
+}
+
+func createTracker(t *testing.T, svc *Service, dryRun bool) (Tracker, string) {
+	t.Helper()
+
+	team := dbtest.Team()
+
+	trk, err := svc.Create(context.Background(), team, TrackerInput{
+		Kind:    KindJira,
+		BaseURL: "https://" + testHost,
+		Token:   testToken,
+		DryRun:  dryRun,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if err := svc.Delete(context.Background(), trk.ID, team); err != nil {
+			t.Error(err)
+		}
+	})
+
+	return trk, team
+}
+
+func syncStatus(t *testing.T, svc *Service, team string) string {
+	t.Helper()
+
+	trackers, err := svc.List(context.Background(), team)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return trackers[0].SyncStatus
+}
+
+func TestService_Create_encryptsToken(t *testing.T) {
+	svc := newTestService(t, NewFake(testHost))
+	trk, _ := createTracker(t, svc, false)
+
+	entity, err := svc.repo.Tracker(context.Background(), trk.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(entity.Token, testToken) {
+		t.Errorf("token saved in clear: %s", entity.Token)
+	}
+}
+
+func TestService_SetEstimate(t *testing.T) {
+	fake := NewFake(testHost)
+	svc := newTestService(t, fake)
+	ctx := context.Background()
+	_, team := createTracker(t, svc, false)
+
+	if err := svc.SetEstimate(ctx, team, "https://"+testHost+"/SIZE-1", Estimate{Value: "3"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if est, found := fake.Estimate("SIZE-1"); !found || est.Value != "3" {
+		t.Errorf("estimate = %+v, %t", est, found)
+	}
+
+	if status := syncStatus(t, svc, team); status != "Set estimate 3 of SIZE-1" {
+		t.Errorf("sync status = %s", status)
+	}
+
+	// issues of other trackers are left unchanged
+	if err := svc.SetEstimate(ctx, team, "https://other.example.com/SIZE-2", Estimate{Value: "5"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, found := fake.Estimate("SIZE-2"); found {
+		t.Error("estimate set on an issue of another tracker")
+	}
+}
+
+func TestService_SetEstimate_dryRun(t *testing.T) {
+	fake := NewFake(testHost)
+	svc := newTestService(t, fake)
+	_, team := createTracker(t, svc, true)
+
+	if err := svc.SetEstimate(context.Background(), team, "https://"+testHost+"/SIZE-1", Estimate{Value: "3"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, found := fake.Estimate("SIZE-1"); found {
+		t.Error("estimate set in dry-run mode")
+	}
+
+	if status := syncStatus(t, svc, team); status != "Dry run: would have set estimate 3 of SIZE-1" {
+		t.Errorf("sync status = %s", status)
+	}
+}
@@ -0,0 +1,110 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+const (
+	maxPageSize  = 256 << 10
+	maxRedirects = 3
+)
+
+// pageTitles reads the title of web pages, on allowed hosts only.
+type pageTitles struct {
+	hosts  []string
+	client *http.Client
+}
+
+func newPageTitles(hosts []string, timeout time.Duration) *pageTitles {
+	res := &pageTitles{hosts: hosts}
+
+	res.client = &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return errors.New("too many redirects")
+			}
+
+			if !res.allowed(req.URL) {
+				return fmt.Errorf("redirect to %s is not allowed", req.URL.Host)
+			}
+
+			return nil
+		},
+	}
+
+	return res
+}
+
+// allowed reports whether the host of u is one of the allowed hosts, or one of their subdomains.
+func (pt *pageTitles) allowed(u *url.URL) bool {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return false
+	}
+
+	host := strings.ToLower(u.Hostname())
+
+	for _, allowed := range pt.hosts {
+		allowed = strings.ToLower(strings.TrimSpace(allowed))
+
+		if allowed != "" && (host == allowed || strings.HasSuffix(host, "."+allowed)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (pt *pageTitles) title(ctx context.Context, u *url.URL) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Accept", "text/html")
+
+	resp, err := pt.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GET %s: %s", u, resp.Status)
+	}
+
+	return parseTitle(io.LimitReader(resp.Body, maxPageSize)), nil
+}
+
+// parseTitle returns the text of the first title element, with spaces collapsed.
+func parseTitle(r io.Reader) string {
+	tokenizer := html.NewTokenizer(r)
+
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return ""
+		case html.StartTagToken:
+			if name, _ := tokenizer.TagName(); string(name) != "title" {
+				continue
+			}
+
+			if tokenizer.Next() != html.TextToken {
+				return ""
+			}
+
+			return strings.Join(strings.Fields(string(tokenizer.Text())), " ")
+		default:
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/MartyHub/size-it/internal"
+	"github.com/MartyHub/size-it/internal/db"
+	"github.com/MartyHub/size-it/internal/db/sqlc"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// HeaderSignature holds the hex encoded HMAC-SHA256 of the body, keyed by the secret of the webhook.
+	HeaderSignature = "X-SizeIt-Signature-256"
+	HeaderEvent     = "X-SizeIt-Event"
+	HeaderDelivery  = "X-SizeIt-Delivery"
+
+	userAgent = "SizeIt-Webhook/1"
+
+	dispatchTick  = 5 * time.Second
+	firstBackoff  = 30 * time.Second
+	maxAttempts   = 6
+	maxDeliveries = 16
+)
+
+// newClient returns a client refusing to connect to internal addresses,
+// which the host names of webhooks may resolve to.
+func newClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Control: func(_, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+
+			if internalAddr(addrPort.Addr()) {
+				return fmt.Errorf("address %s is internal", addrPort.Addr())
+			}
+
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// run sends due deliveries on every tick, or when woken up, until done is closed.
+// Deliveries are claimed with a lease, so that replicas don't send them twice.
+func (svc *Service) run(done <-chan struct{}, timeout time.Duration) {
+	slog.Info("Starting webhook dispatcher", slog.String("tick", dispatchTick.String()))
+
+	ticker := svc.clk.NewTicker(dispatchTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			slog.Info("Server is shutting down, stopping webhook dispatcher...")
+
+			return
+		case <-ticker.C():
+		case <-svc.wake:
+		}
+
+		if err := svc.dispatch(timeout); err != nil {
+			internal.LogError("Failed to dispatch webhook deliveries", err)
+		}
+	}
+}
+
+func (svc *Service) dispatch(timeout time.Duration) error {
+	ctx := context.Background()
+	now := svc.clk.Now()
+
+	deliveries, err := svc.repo.ClaimWebhookDeliveries(ctx, sqlc.ClaimWebhookDeliveriesParams{
+		LeaseUntil: pgtype.Timestamp{Time: now.Add(2 * timeout), Valid: true}, //nolint:mnd
+		Now:        pgtype.Timestamp{Time: now, Valid: true},
+		MaxCount:   maxDeliveries,
+	})
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+
+	for _, dlv := range deliveries {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if err := svc.deliver(ctx, dlv, timeout); err != nil {
+				internal.LogError("Failed to update webhook delivery", err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return nil
+}
+
+// deliver sends the delivery, then records its outcome and schedules a retry on failure.
+func (svc *Service) deliver(ctx context.Context, dlv sqlc.WebhookDelivery, timeout time.Duration) error {
+	wh, err := svc.repo.Webhook(ctx, dlv.WebhookID)
+	if err != nil {
+		if db.IsErrNoRows(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	status, err := svc.send(sendCtx, wh, dlv)
+
+	now := svc.clk.Now()
+	params := sqlc.UpdateWebhookDeliveryParams{
+		Status:         statusDelivered,
+		Attempts:       dlv.Attempts + 1,
+		ResponseStatus: int32(status), //nolint:gosec
+		NextAttemptAt:  pgtype.Timestamp{Time: now, Valid: true},
+		ID:             dlv.ID,
+	}
+
+	if err == nil {
+		params.DeliveredAt = pgtype.Timestamp{Time: now, Valid: true}
+	} else {
+		params.Error = truncate(err.Error(), maxErrorSize)
+
+		if params.Attempts < maxAttempts {
+			params.Status = statusPending
+			params.NextAttemptAt.Time = now.Add(backoff(int(params.Attempts)))
+		} else {
+			params.Status = statusFailed
+		}
+	}
+
+	slog.Info("Sent webhook delivery",
+		slog.Int64("delivery", dlv.ID),
+		slog.String(internal.LogKeyURI, wh.Url),
+		slog.String(internal.LogKeyEvent, dlv.Event),
+		slog.Int(internal.LogKeyStatus, status),
+		slog.String("outcome", params.Status),
+	)
+
+	return svc.repo.UpdateWebhookDelivery(ctx, params)
+}
+
+// send posts the signed payload, a delivery succeeds on any 2xx response.
+func (svc *Service) send(ctx context.Context, wh sqlc.Webhook, dlv sqlc.WebhookDelivery) (int, error) {
+	secret, err := svc.secrets.OpenSecret(wh.Secret)
+	if err != nil {
+		return 0, fmt.Errorf("secret of webhook %d: %w", wh.ID, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wh.Url, bytes.NewReader(dlv.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(dlv.ID, 10))
+	req.Header.Set(HeaderEvent, dlv.Event)
+	req.Header.Set(HeaderSignature, Sign(secret, dlv.Payload))
+
+	resp, err := svc.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxErrorSize))
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// Sign returns the value of the signature header of the payload, so receivers can check it the same way.
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// backoff doubles the delay before the next attempt after each failed one.
+func backoff(attempts int) time.Duration {
+	return firstBackoff << (attempts - 1)
+}
+
+func truncate(s string, size int) string {
+	if runes := []rune(s); len(runes) > size {
+		return string(runes[:size])
+	}
+
+	return s
+}
@@ -0,0 +1,427 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MartyHub/size-it/internal"
+	"github.com/MartyHub/size-it/internal/db/dbtest"
+	"github.com/MartyHub/size-it/internal/db/sqlc"
+	"github.com/MartyHub/size-it/internal/pubsub"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	testSecret  = "secret"
+	testTimeout = time.Second
+)
+
+type (
+	// receiver records the deliveries it receives, answering with the given statuses, then 204.
+	receiver struct {
+		*httptest.Server
+
+		mu       sync.Mutex
+		requests []received
+		statuses []int
+	}
+
+	received struct {
+		header http.Header
+		body   []byte
+	}
+)
+
+func newReceiver(t *testing.T, statuses ...int) *receiver {
+	t.Helper()
+
+	res := &receiver{statuses: statuses}
+	res.Server = httptest.NewServer(http.HandlerFunc(res.receive))
+
+	t.Cleanup(res.Close)
+
+	return res
+}
+
+func (rcv *receiver) receive(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+
+	rcv.requests = append(rcv.requests, received{header: r.Header, body: body})
+
+	status := http.StatusNoContent
+
+	if len(rcv.statuses) > 0 {
+		status = rcv.statuses[0]
+		rcv.statuses = rcv.statuses[1:]
+	}
+
+	w.WriteHeader(status)
+}
+
+func (rcv *receiver) received() []received {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+
+	return rcv.requests
+}
+
+func testSecrets(t *testing.T) *internal.CookieCodec {
+	t.Helper()
+
+	res, err := internal.NewCookieCodec(internal.Config{CookieKeys: []string{strings.Repeat("k", 32)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return res
+}
+
+func TestService_send(t *testing.T) {
+	rcv := newReceiver(t, http.StatusInternalServerError)
+	secrets := testSecrets(t)
+	svc := NewService(&internal.UTCClock{}, nil, rcv.Client(), secrets)
+	ctx := context.Background()
+	dlv := sqlc.WebhookDelivery{ID: 42, WebhookID: 1, Event: pubsub.TicketSized, Payload: []byte(`{"type":"ticket.sized"}`)}
+
+	sealed, err := secrets.SealSecret(testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// secrets saved before being encrypted are still used as is
+	wh := sqlc.Webhook{ID: 1, Url: rcv.URL, Secret: testSecret}
+
+	if status, err := svc.send(ctx, wh, dlv); err == nil || status != http.StatusInternalServerError {
+		t.Errorf("send = %d, %v, want an error on status 500", status, err)
+	}
+
+	wh.Secret = sealed
+
+	if status, err := svc.send(ctx, wh, dlv); err != nil || status != http.StatusNoContent {
+		t.Fatalf("send = %d, %v", status, err)
+	}
+
+	for _, req := range rcv.received() {
+		if got, want := req.header.Get(HeaderSignature), Sign(testSecret, req.body); got != want {
+			t.Errorf("signature = %s, want %s", got, want)
+		}
+
+		if got := req.header.Get(HeaderEvent); got != pubsub.TicketSized {
+			t.Errorf("event = %s", got)
+		}
+
+		if got := req.header.Get(HeaderDelivery); got != "42" {
+			t.Errorf("delivery = %s", got)
+		}
+
+		if string(req.body) != string(dlv.Payload) {
+			t.Errorf("body = %s", req.body)
+		}
+	}
+}
+
+func TestSign(t *testing.T) {
+	// as computed by: printf 'payload' | openssl dgst -sha256 -hmac secret
+	want := "sha256=b82fcb791acec57859b989b430a826488ce2e479fdf92326bd0a2e8375a42ba4"
+
+	if got := Sign(testSecret, []byte("payload")); got != want {
+		t.Errorf("Sign = %s, want %s", got, want)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	want := []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute}
+
+	for i, delay := range want {
+		if got := backoff(i + 1); got != delay {
+			t.Errorf("backoff(%d) = %s, want %s", i+1, got, delay)
+		}
+	}
+}
+
+func TestNewClient_rejectsInternalAddresses(t *testing.T) {
+	rcv := newReceiver(t)
+
+	resp, err := newClient(testTimeout).Get(rcv.URL) //nolint:noctx
+	if err == nil {
+		resp.Body.Close()
+
+		t.Fatal("connected to a loopback address")
+	}
+
+	if len(rcv.received()) > 0 {
+		t.Error("delivery received")
+	}
+}
+
+// testService returns a service using the test database, see dbtest.
+func testService(t *testing.T, client *http.Client) *Service {
+	t.Helper()
+
+	repo := dbtest.Repository(t)
+
+	return NewService(internal.NewFixedClock(time.Now().UTC().Truncate(time.Second)), repo, client, testSecrets(t))
+}
+
+// testWebhook creates a webhook of a team of its own to the URL, deleted with its deliveries by the end of the test.
+func testWebhook(t *testing.T, svc *Service, url string) (Webhook, string) {
+	t.Helper()
+
+	ctx := context.Background()
+	team := dbtest.Team()
+
+	wh, err := svc.Create(ctx, team, WebhookInput{URL: url, Secret: testSecret, Events: []string{pubsub.TicketSized}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if err := svc.Delete(context.Background(), wh.ID, team); err != nil {
+			t.Error(err)
+		}
+	})
+
+	return wh, team
+}
+
+// dispatchAt sends the deliveries due after the given delay since the start of the test.
+func dispatchAt(t *testing.T, svc *Service, start time.Time, delay time.Duration) {
+	t.Helper()
+
+	svc.clk = internal.NewFixedClock(start.Add(delay))
+
+	if err := svc.dispatch(testTimeout); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func deliveries(t *testing.T, svc *Service, wh Webhook, team string) []Delivery {
+	t.Helper()
+
+	res, err := svc.Deliveries(context.Background(), wh.ID, team)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return res
+}
+
+func TestService_Create_encryptsSecret(t *testing.T) {
+	svc := testService(t, nil)
+	wh, _ := testWebhook(t, svc, "https://hooks.example.com")
+
+	entity, err := svc.repo.Webhook(context.Background(), wh.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(entity.Secret, testSecret) {
+		t.Errorf("secret saved in clear: %s", entity.Secret)
+	}
+}
+
+func TestService_dispatch_retries(t *testing.T) {
+	rcv := newReceiver(t, http.StatusBadGateway, http.StatusServiceUnavailable)
+	svc := testService(t, rcv.Client())
+	start := svc.clk.Now()
+	wh, team := testWebhook(t, svc, rcv.URL)
+
+	if err := svc.doEnqueue(context.Background(), pubsub.Event{Type: pubsub.TicketSized, Team: team}); err != nil {
+		t.Fatal(err)
+	}
+
+	dispatchAt(t, svc, start, 0)
+	dispatchAt(t, svc, start, firstBackoff-time.Second)
+
+	if got := len(rcv.received()); got != 1 {
+		t.Fatalf("sent %d times before the first backoff", got)
+	}
+
+	dispatchAt(t, svc, start, firstBackoff)
+	dispatchAt(t, svc, start, firstBackoff+2*firstBackoff-time.Second)
+
+	if got := len(rcv.received()); got != 2 { //nolint:mnd
+		t.Fatalf("sent %d times before the second backoff", got)
+	}
+
+	dispatchAt(t, svc, start, firstBackoff+2*firstBackoff)
+
+	dlvs := deliveries(t, svc, wh, team)
+
+	if len(dlvs) != 1 || dlvs[0].Status != statusDelivered || dlvs[0].Attempts != 3 {
+		t.Errorf("unexpected deliveries %+v", dlvs)
+	}
+}
+
+// eventually waits until cond holds, as deliveries are sent and recorded by the dispatcher in the background.
+func eventually(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+
+		time.Sleep(10 * time.Millisecond) //nolint:mnd
+	}
+}
+
+func TestService_run(t *testing.T) {
+	rcv := newReceiver(t, http.StatusBadGateway)
+	clk := internal.NewFixedClock(time.Now().UTC().Truncate(time.Second))
+	svc := NewService(clk, dbtest.Repository(t), rcv.Client(), testSecrets(t))
+	done := make(chan struct{})
+	wh, team := testWebhook(t, svc, rcv.URL)
+	received := func(count int) func() bool {
+		return func() bool { return len(rcv.received()) == count }
+	}
+
+	t.Cleanup(func() { close(done) })
+
+	go svc.run(done, testTimeout)
+
+	// woken up by the new delivery
+	if err := svc.doEnqueue(context.Background(), pubsub.Event{Type: pubsub.TicketSized, Team: team}); err != nil {
+		t.Fatal(err)
+	}
+
+	eventually(t, received(1))
+
+	// not retried before the backoff
+	clk.Advance(firstBackoff - dispatchTick)
+	time.Sleep(100 * time.Millisecond) //nolint:mnd
+
+	if got := len(rcv.received()); got != 1 {
+		t.Fatalf("sent %d times before the backoff", got)
+	}
+
+	// retried on the first tick after it
+	clk.Advance(dispatchTick)
+
+	eventually(t, received(2)) //nolint:mnd
+	eventually(t, func() bool {
+		dlvs := deliveries(t, svc, wh, team)
+
+		return len(dlvs) == 1 && dlvs[0].Status == statusDelivered
+	})
+}
+
+func TestService_dispatch_fails(t *testing.T) {
+	statuses := make([]int, maxAttempts)
+
+	for i := range statuses {
+		statuses[i] = http.StatusInternalServerError
+	}
+
+	rcv := newReceiver(t, statuses...)
+	svc := testService(t, rcv.Client())
+	start := svc.clk.Now()
+	wh, team := testWebhook(t, svc, rcv.URL)
+
+	if err := svc.doEnqueue(context.Background(), pubsub.Event{Type: pubsub.TicketSized, Team: team}); err != nil {
+		t.Fatal(err)
+	}
+
+	var delay time.Duration
+
+	for attempts := 1; attempts <= maxAttempts; attempts++ {
+		dispatchAt(t, svc, start, delay)
+
+		if attempts < maxAttempts {
+			delay += backoff(attempts)
+		}
+	}
+
+	dispatchAt(t, svc, start, delay+time.Hour)
+
+	dlvs := deliveries(t, svc, wh, team)
+
+	if len(dlvs) != 1 || !dlvs[0].Failed() || dlvs[0].Attempts != maxAttempts || dlvs[0].NextAttemptAt != nil {
+		t.Errorf("unexpected deliveries %+v", dlvs)
+	}
+
+	if got := len(rcv.received()); got != maxAttempts {
+		t.Errorf("sent %d times, want %d", got, maxAttempts)
+	}
+}
+
+func TestService_dispatch_leaseExpiry(t *testing.T) {
+	rcv := newReceiver(t)
+	svc := testService(t, rcv.Client())
+	start := svc.clk.Now()
+	wh, team := testWebhook(t, svc, rcv.URL)
+
+	if err := svc.doEnqueue(context.Background(), pubsub.Event{Type: pubsub.TicketSized, Team: team}); err != nil {
+		t.Fatal(err)
+	}
+
+	// claimed by a replica which stopped before sending it
+	if _, err := svc.repo.ClaimWebhookDeliveries(context.Background(), sqlc.ClaimWebhookDeliveriesParams{
+		LeaseUntil: pgtype.Timestamp{Time: start.Add(2 * testTimeout), Valid: true},
+		Now:        pgtype.Timestamp{Time: start, Valid: true},
+		MaxCount:   maxDeliveries,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	dispatchAt(t, svc, start, testTimeout)
+
+	if got := len(rcv.received()); got != 0 {
+		t.Fatalf("sent %d times during the lease", got)
+	}
+
+	dispatchAt(t, svc, start, 2*testTimeout)
+
+	if dlvs := deliveries(t, svc, wh, team); len(dlvs) != 1 || dlvs[0].Status != statusDelivered {
+		t.Errorf("unexpected deliveries %+v", dlvs)
+	}
+}
+
+func TestService_Redeliver(t *testing.T) {
+	rcv := newReceiver(t)
+	svc := testService(t, rcv.Client())
+	start := svc.clk.Now()
+	ctx := context.Background()
+	wh, team := testWebhook(t, svc, rcv.URL)
+
+	if err := svc.doEnqueue(ctx, pubsub.Event{Type: pubsub.TicketSized, Team: team}); err != nil {
+		t.Fatal(err)
+	}
+
+	dispatchAt(t, svc, start, 0)
+
+	first := deliveries(t, svc, wh, team)[0]
+
+	if _, err := svc.Redeliver(ctx, wh.ID, first.ID, "other-team"); err == nil {
+		t.Error("redelivered a webhook of another team")
+	}
+
+	again, err := svc.Redeliver(ctx, wh.ID, first.ID, team)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dispatchAt(t, svc, start, time.Second)
+
+	requests := rcv.received()
+
+	if len(requests) != 2 || string(requests[0].body) != string(requests[1].body) { //nolint:mnd
+		t.Fatalf("unexpected requests %+v", requests)
+	}
+
+	if got := requests[1].header.Get(HeaderDelivery); got != strconv.FormatInt(again.ID, 10) {
+		t.Errorf("delivery = %s, want %d", got, again.ID)
+	}
+}
@@ -0,0 +1,283 @@
+package webhook
+
+import (
+	"net/http"
+	"path"
+	"strconv"
+
+	"github.com/MartyHub/size-it/internal"
+	"github.com/MartyHub/size-it/internal/openapi"
+	"github.com/MartyHub/size-it/internal/pubsub"
+	"github.com/MartyHub/size-it/internal/server"
+	"github.com/labstack/echo/v4"
+)
+
+const tagWebhooks = "webhooks"
+
+func Register(srv *server.Server) {
+	hdl := &handler{
+		path: srv.Cfg.Path,
+		svc:  NewService(srv.Clk, srv.Repo, newClient(srv.Cfg.WebhookTimeout), srv.Cookies),
+	}
+
+	srv.PubSub.Subscribe(hdl.svc.enqueue)
+
+	go hdl.svc.run(srv.Done(), srv.Cfg.WebhookTimeout)
+
+	srv.GET("/webhooks", hdl.list)
+	srv.POST("/webhooks", hdl.create)
+	srv.GET("/webhooks/:webhookID", hdl.get)
+	srv.DELETE("/webhooks/:webhookID", hdl.delete)
+	srv.POST("/webhooks/:webhookID/deliveries/:deliveryID", hdl.redeliver)
+
+	srv.API(http.MethodGet, "/api/v1/webhooks", hdl.apiList, openapi.Route{
+		Summary: "List webhooks of the team of the user",
+		Tags:    []string{tagWebhooks},
+		Output:  []Webhook{},
+	})
+	srv.API(http.MethodPost, "/api/v1/webhooks", hdl.apiCreate, openapi.Route{
+		Summary: "Subscribe a URL to events of the team, deliveries are signed with the secret",
+		Tags:    []string{tagWebhooks},
+		Input:   WebhookInput{},
+		Output:  Webhook{},
+		Status:  http.StatusCreated,
+	})
+	srv.API(http.MethodDelete, "/api/v1/webhooks/:webhookID", hdl.apiDelete, openapi.Route{
+		Summary: "Delete a webhook with its deliveries",
+		Tags:    []string{tagWebhooks},
+		Input:   WebhookParams{},
+		Status:  http.StatusNoContent,
+	})
+	srv.API(http.MethodGet, "/api/v1/webhooks/:webhookID/deliveries", hdl.apiDeliveries, openapi.Route{
+		Summary: "List the latest deliveries of a webhook",
+		Tags:    []string{tagWebhooks},
+		Input:   WebhookParams{},
+		Output:  []Delivery{},
+	})
+	srv.API(http.MethodPost, "/api/v1/webhooks/:webhookID/deliveries/:deliveryID", hdl.apiRedeliver, openapi.Route{
+		Summary: "Send the payload of a delivery again",
+		Tags:    []string{tagWebhooks},
+		Input:   DeliveryParams{},
+		Output:  Delivery{},
+		Status:  http.StatusCreated,
+	})
+}
+
+type handler struct {
+	path string
+	svc  *Service
+}
+
+func (hdl *handler) list(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetTeamUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	webhooks, err := hdl.svc.List(ctx, usr.Team)
+	if err != nil {
+		return err
+	}
+
+	return c.Render(http.StatusOK, "webhooks.gohtml", map[string]any{
+		"events":   pubsub.EventTypes(),
+		"path":     hdl.path,
+		"user":     usr,
+		"webhooks": webhooks,
+	})
+}
+
+func (hdl *handler) create(c echo.Context) error {
+	input, err := internal.Bind[WebhookInput](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetTeamUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err = hdl.svc.Create(ctx, usr.Team, input); err != nil {
+		return err
+	}
+
+	return c.Redirect(http.StatusFound, path.Join(hdl.path, "webhooks"))
+}
+
+func (hdl *handler) get(c echo.Context) error {
+	input, err := internal.Bind[WebhookParams](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetTeamUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	wh, err := hdl.svc.Get(ctx, input.ID, usr.Team)
+	if err != nil {
+		return err
+	}
+
+	deliveries, err := hdl.svc.Deliveries(ctx, input.ID, usr.Team)
+	if err != nil {
+		return err
+	}
+
+	return c.Render(http.StatusOK, "webhook.gohtml", map[string]any{
+		"deliveries": deliveries,
+		"path":       hdl.path,
+		"user":       usr,
+		"webhook":    wh,
+	})
+}
+
+// delete is called by htmx, which then follows the HX-Redirect header.
+func (hdl *handler) delete(c echo.Context) error {
+	input, err := internal.Bind[WebhookParams](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetTeamUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = hdl.svc.Delete(ctx, input.ID, usr.Team); err != nil {
+		return err
+	}
+
+	c.Response().Header().Set("HX-Redirect", path.Join(hdl.path, "webhooks"))
+
+	return c.NoContent(http.StatusOK)
+}
+
+func (hdl *handler) redeliver(c echo.Context) error {
+	input, err := internal.Bind[DeliveryParams](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetTeamUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err = hdl.svc.Redeliver(ctx, input.WebhookID, input.DeliveryID, usr.Team); err != nil {
+		return err
+	}
+
+	return c.Redirect(http.StatusFound, path.Join(hdl.path, "webhooks", strconv.FormatInt(input.WebhookID, 10)))
+}
+
+func (hdl *handler) apiList(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetTeamUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	webhooks, err := hdl.svc.List(ctx, usr.Team)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, webhooks)
+}
+
+func (hdl *handler) apiCreate(c echo.Context) error {
+	input, err := internal.Bind[WebhookInput](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetTeamUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	wh, err := hdl.svc.Create(ctx, usr.Team, input)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, wh)
+}
+
+func (hdl *handler) apiDelete(c echo.Context) error {
+	input, err := internal.Bind[WebhookParams](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetTeamUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = hdl.svc.Delete(ctx, input.ID, usr.Team); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (hdl *handler) apiDeliveries(c echo.Context) error {
+	input, err := internal.Bind[WebhookParams](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetTeamUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	deliveries, err := hdl.svc.Deliveries(ctx, input.ID, usr.Team)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, deliveries)
+}
+
+func (hdl *handler) apiRedeliver(c echo.Context) error {
+	input, err := internal.Bind[DeliveryParams](c)
+	if err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	usr, err := internal.GetTeamUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	dlv, err := hdl.svc.Redeliver(ctx, input.WebhookID, input.DeliveryID, usr.Team)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, dlv)
+}
@@ -0,0 +1,185 @@
+package webhook
+
+import (
+	"errors"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/MartyHub/size-it/internal/db/sqlc"
+	"github.com/MartyHub/size-it/internal/pubsub"
+	"github.com/invopop/validation"
+)
+
+// Statuses of deliveries.
+const (
+	statusDelivered = "delivered"
+	statusFailed    = "failed"
+	statusPending   = "pending"
+)
+
+const (
+	maxErrorSize  = 512
+	maxSecretSize = 128
+	maxURLSize    = 512
+)
+
+type (
+	// Webhook subscribes a URL to events of a team, its secret is never returned.
+	Webhook struct {
+		ID        int64     `json:"id"`
+		URL       string    `json:"url"`
+		Events    []string  `json:"events"`
+		CreatedAt time.Time `json:"createdAt"`
+	}
+
+	Delivery struct {
+		ID             int64      `json:"id"`
+		WebhookID      int64      `json:"webhookId"`
+		Event          string     `json:"event"`
+		Payload        string     `json:"payload"`
+		Status         string     `json:"status"`
+		Attempts       int        `json:"attempts"`
+		ResponseStatus int        `json:"responseStatus,omitempty"`
+		Error          string     `json:"error,omitempty"`
+		CreatedAt      time.Time  `json:"createdAt"`
+		NextAttemptAt  *time.Time `json:"nextAttemptAt,omitempty"`
+		DeliveredAt    *time.Time `json:"deliveredAt,omitempty"`
+	}
+
+	WebhookInput struct {
+		URL    string   `form:"url"    json:"url"`
+		Secret string   `form:"secret" json:"secret"`
+		Events []string `form:"events" json:"events"`
+	}
+
+	WebhookParams struct {
+		ID int64 `param:"webhookID"`
+	}
+
+	DeliveryParams struct {
+		WebhookID  int64 `param:"webhookID"`
+		DeliveryID int64 `param:"deliveryID"`
+	}
+)
+
+func (input WebhookInput) Validate() error {
+	return validation.ValidateStruct(&input,
+		validation.Field(&input.URL,
+			validation.Required,
+			validation.RuneLength(1, maxURLSize),
+			validation.By(validateURL),
+		),
+		validation.Field(&input.Secret, validation.Required, validation.RuneLength(1, maxSecretSize)),
+		validation.Field(&input.Events,
+			validation.Required,
+			validation.Each(validation.In(toAny(pubsub.EventTypes())...)),
+		),
+	)
+}
+
+func (params WebhookParams) Validate() error {
+	return validation.ValidateStruct(&params,
+		validation.Field(&params.ID, validation.Required),
+	)
+}
+
+func (params DeliveryParams) Validate() error {
+	return validation.ValidateStruct(&params,
+		validation.Field(&params.WebhookID, validation.Required),
+		validation.Field(&params.DeliveryID, validation.Required),
+	)
+}
+
+// Pending reports whether the delivery will be attempted again.
+func (dlv Delivery) Pending() bool {
+	return dlv.Status == statusPending
+}
+
+func (dlv Delivery) Failed() bool {
+	return dlv.Status == statusFailed
+}
+
+func validateURL(value any) error {
+	s, _ := value.(string)
+
+	u, err := url.ParseRequestURI(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return errors.New("must be a valid http(s) URL")
+	}
+
+	if internalHost(u.Hostname()) {
+		return errors.New("must not target a private, loopback or link-local address")
+	}
+
+	return nil
+}
+
+// internalHost reports whether the host names this machine, or is the IP address of an internal network.
+// Host names resolving to such addresses are rejected when connecting, see newClient.
+func internalHost(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+
+	addr, err := netip.ParseAddr(host)
+
+	return err == nil && internalAddr(addr)
+}
+
+func internalAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	return addr.IsLoopback() ||
+		addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() ||
+		addr.IsUnspecified()
+}
+
+func toAny(values []string) []any {
+	res := make([]any, len(values))
+
+	for i, value := range values {
+		res[i] = value
+	}
+
+	return res
+}
+
+func toWebhook(entity sqlc.Webhook) Webhook {
+	return Webhook{
+		ID:        entity.ID,
+		URL:       entity.Url,
+		Events:    entity.Events,
+		CreatedAt: entity.CreatedAt.Time,
+	}
+}
+
+func toDelivery(entity sqlc.WebhookDelivery) Delivery {
+	res := Delivery{
+		ID:             entity.ID,
+		WebhookID:      entity.WebhookID,
+		Event:          entity.Event,
+		Payload:        string(entity.Payload),
+		Status:         entity.Status,
+		Attempts:       int(entity.Attempts),
+		ResponseStatus: int(entity.ResponseStatus),
+		Error:          entity.Error,
+		CreatedAt:      entity.CreatedAt.Time,
+	}
+
+	if entity.Status == statusPending {
+		res.NextAttemptAt = &entity.NextAttemptAt.Time
+	}
+
+	if entity.DeliveredAt.Valid {
+		res.DeliveredAt = &entity.DeliveredAt.Time
+	}
+
+	return res
+}
@@ -0,0 +1,36 @@
+package webhook
+
+import (
+	"testing"
+
+	"github.com/MartyHub/size-it/internal/pubsub"
+)
+
+func TestWebhookInput_Validate(t *testing.T) {
+	tests := map[string]bool{
+		"https://hooks.example.com/size-it": true,
+		"http://203.0.113.7:8080/hook":      true,
+		"ftp://hooks.example.com":           false,
+		"/hook":                             false,
+		"http://localhost:8080/hook":        false,
+		"http://api.localhost/hook":         false,
+		"http://127.0.0.1/hook":             false,
+		"http://0.0.0.0/hook":               false,
+		"http://10.1.2.3/hook":              false,
+		"http://172.16.0.1/hook":            false,
+		"http://192.168.1.1/hook":           false,
+		"http://169.254.169.254/latest":     false,
+		"http://[::1]/hook":                 false,
+		"http://[fe80::1]/hook":             false,
+		"http://[fd00::1]/hook":             false,
+		"http://[::ffff:127.0.0.1]/hook":    false,
+	}
+
+	for url, valid := range tests {
+		input := WebhookInput{URL: url, Secret: "secret", Events: []string{pubsub.TicketSized}}
+
+		if err := input.Validate(); (err == nil) != valid {
+			t.Errorf("Validate(%s) = %v, want valid %t", url, err, valid)
+		}
+	}
+}
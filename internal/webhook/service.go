@@ -0,0 +1,212 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/MartyHub/size-it/internal"
+	"github.com/MartyHub/size-it/internal/db"
+	"github.com/MartyHub/size-it/internal/db/sqlc"
+	"github.com/MartyHub/size-it/internal/pubsub"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type Service struct {
+	clk     internal.Clock
+	repo    *db.Repository
+	client  *http.Client
+	secrets *internal.CookieCodec
+	wake    chan struct{}
+}
+
+// NewService sends deliveries with the given client, so they can be tested against a local server.
+// Secrets of webhooks are encrypted with secrets.
+func NewService(
+	clk internal.Clock,
+	repo *db.Repository,
+	client *http.Client,
+	secrets *internal.CookieCodec,
+) *Service {
+	return &Service{
+		clk:     clk,
+		repo:    repo,
+		client:  client,
+		secrets: secrets,
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+func (svc *Service) List(ctx context.Context, team string) ([]Webhook, error) {
+	entities, err := svc.repo.Webhooks(ctx, team)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]Webhook, len(entities))
+
+	for i, entity := range entities {
+		res[i] = toWebhook(entity)
+	}
+
+	return res, nil
+}
+
+// Get returns the webhook if it belongs to the team.
+func (svc *Service) Get(ctx context.Context, id int64, team string) (Webhook, error) {
+	entity, err := svc.repo.Webhook(ctx, id)
+	if err != nil {
+		if db.IsErrNoRows(err) {
+			return Webhook{}, fmt.Errorf("%w: webhook %d", internal.ErrNotFound, id)
+		}
+
+		return Webhook{}, err
+	}
+
+	if entity.Team != team {
+		return Webhook{}, fmt.Errorf("%w: webhook %d", internal.ErrNotFound, id)
+	}
+
+	return toWebhook(entity), nil
+}
+
+func (svc *Service) Create(ctx context.Context, team string, input WebhookInput) (Webhook, error) {
+	secret, err := svc.secrets.SealSecret(input.Secret)
+	if err != nil {
+		return Webhook{}, err
+	}
+
+	entity, err := svc.repo.CreateWebhook(ctx, sqlc.CreateWebhookParams{
+		Team:      team,
+		Url:       input.URL,
+		Secret:    secret,
+		Events:    input.Events,
+		CreatedAt: pgtype.Timestamp{Time: svc.clk.Now(), Valid: true},
+	})
+	if err != nil {
+		return Webhook{}, err
+	}
+
+	slog.Info("Created webhook",
+		slog.Int64("webhook", entity.ID),
+		slog.String("team", team),
+		slog.Any("events", input.Events),
+	)
+
+	return toWebhook(entity), nil
+}
+
+// Delete removes the webhook with its deliveries.
+func (svc *Service) Delete(ctx context.Context, id int64, team string) error {
+	if _, err := svc.Get(ctx, id, team); err != nil {
+		return err
+	}
+
+	return svc.repo.DeleteWebhook(ctx, id)
+}
+
+// Deliveries returns the latest deliveries of the webhook, most recent first.
+func (svc *Service) Deliveries(ctx context.Context, id int64, team string) ([]Delivery, error) {
+	if _, err := svc.Get(ctx, id, team); err != nil {
+		return nil, err
+	}
+
+	entities, err := svc.repo.WebhookDeliveries(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]Delivery, len(entities))
+
+	for i, entity := range entities {
+		res[i] = toDelivery(entity)
+	}
+
+	return res, nil
+}
+
+// Redeliver sends the payload of a previous delivery again, as a new delivery.
+func (svc *Service) Redeliver(ctx context.Context, webhookID, deliveryID int64, team string) (Delivery, error) {
+	if _, err := svc.Get(ctx, webhookID, team); err != nil {
+		return Delivery{}, err
+	}
+
+	entity, err := svc.repo.WebhookDelivery(ctx, deliveryID)
+	if err != nil {
+		if db.IsErrNoRows(err) {
+			return Delivery{}, fmt.Errorf("%w: delivery %d", internal.ErrNotFound, deliveryID)
+		}
+
+		return Delivery{}, err
+	}
+
+	if entity.WebhookID != webhookID {
+		return Delivery{}, fmt.Errorf("%w: delivery %d", internal.ErrNotFound, deliveryID)
+	}
+
+	entity, err = svc.repo.CreateWebhookDelivery(ctx, sqlc.CreateWebhookDeliveryParams{
+		WebhookID: webhookID,
+		Event:     entity.Event,
+		Payload:   entity.Payload,
+		CreatedAt: pgtype.Timestamp{Time: svc.clk.Now(), Valid: true},
+	})
+	if err != nil {
+		return Delivery{}, err
+	}
+
+	svc.wakeUp()
+
+	return toDelivery(entity), nil
+}
+
+// enqueue records a delivery for each webhook of the team subscribed to the event.
+// It is a pubsub handler, the dispatcher sends deliveries asynchronously.
+func (svc *Service) enqueue(ctx context.Context, evt pubsub.Event) {
+	if evt.Team == "" {
+		return
+	}
+
+	if err := svc.doEnqueue(ctx, evt); err != nil {
+		internal.LogError("Failed to enqueue webhook deliveries", err)
+	}
+}
+
+func (svc *Service) doEnqueue(ctx context.Context, evt pubsub.Event) error {
+	webhooks, err := svc.repo.EventWebhooks(ctx, sqlc.EventWebhooksParams{
+		Team:  evt.Team,
+		Event: evt.Type,
+	})
+	if err != nil || len(webhooks) == 0 {
+		return err
+	}
+
+	payload, err := json.Marshal(evt)
+	if err != nil {
+		return err
+	}
+
+	for _, wh := range webhooks {
+		if _, err = svc.repo.CreateWebhookDelivery(ctx, sqlc.CreateWebhookDeliveryParams{
+			WebhookID: wh.ID,
+			Event:     evt.Type,
+			Payload:   payload,
+			CreatedAt: pgtype.Timestamp{Time: svc.clk.Now(), Valid: true},
+		}); err != nil {
+			return err
+		}
+	}
+
+	svc.wakeUp()
+
+	return nil
+}
+
+// wakeUp makes the dispatcher send pending deliveries without waiting for its next tick.
+func (svc *Service) wakeUp() {
+	select {
+	case svc.wake <- struct{}{}:
+	default:
+	}
+}
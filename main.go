@@ -8,8 +8,11 @@ import (
 	"github.com/MartyHub/size-it/internal"
 	"github.com/MartyHub/size-it/internal/db"
 	"github.com/MartyHub/size-it/internal/monitoring"
+	"github.com/MartyHub/size-it/internal/oidc"
 	"github.com/MartyHub/size-it/internal/server"
 	"github.com/MartyHub/size-it/internal/session"
+	"github.com/MartyHub/size-it/internal/tracker"
+	"github.com/MartyHub/size-it/internal/webhook"
 )
 
 func main() {
@@ -46,10 +49,16 @@ func run() error {
 		return err
 	}
 
-	srv := server.NewServer(cfg, repo)
+	srv, err := server.NewServer(cfg, repo)
+	if err != nil {
+		return err
+	}
 
 	monitoring.Register(srv)
+	oidc.Register(srv)
 	session.Register(srv)
+	tracker.Register(srv)
+	webhook.Register(srv)
 
 	return srv.Run(ctx)
 }